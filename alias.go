@@ -0,0 +1,183 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+)
+
+// DeprecationWarning is called each time value of a deprecated flag is set.
+// By default it prints warning to the flag set output.
+var DeprecationWarning = func(fs *flag.FlagSet, name, message string) {
+	fmt.Fprintf(fs.Output(), "flag %q is deprecated: %s\n", name, message)
+}
+
+// Alias defines flag with name old as an alias of the existing flag with name
+// new. Setting value of the alias sets value of the target flag and warns
+// about the deprecated name usage (see DeprecationWarning).
+//
+// Alias is a regular flag within fs, so it is accepted by every parser
+// under the names parser derives from the old name. However, parsers treat
+// alias and its target as the same flag: value of the alias is not set if
+// the target was specified by the previous parsers and vice versa.
+//
+// It panics if flag new doesn't exist or flag old already exists in fs.
+func Alias(fs *flag.FlagSet, old, new string) {
+	if fs.Lookup(new) == nil {
+		panic(fmt.Sprintf(
+			"flagutil: alias: target flag %q must exist",
+			new,
+		))
+	}
+	if fs.Lookup(old) != nil {
+		panic(fmt.Sprintf(
+			"flagutil: alias: flag %q already exists",
+			old,
+		))
+	}
+	fs.Var(&aliasValue{
+		fs:      fs,
+		name:    old,
+		target:  new,
+		message: fmt.Sprintf("use %q instead", new),
+	}, old, "")
+}
+
+// Deprecate marks flag with given name as deprecated. Setting value of the
+// flag warns about its usage with given message (see DeprecationWarning).
+//
+// It panics if flag with given name doesn't exist in fs.
+func Deprecate(fs *flag.FlagSet, name, message string) {
+	f := fs.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf(
+			"flagutil: deprecate: flag %q must exist",
+			name,
+		))
+	}
+	switch v := f.Value.(type) {
+	case *aliasValue:
+		v.message = message
+	case *deprecatedValue:
+		v.message = message
+	default:
+		f.Value = &deprecatedValue{
+			Value:   f.Value,
+			fs:      fs,
+			name:    name,
+			message: message,
+		}
+	}
+}
+
+// deprecation returns deprecation message of the flag and name of the flag
+// it is an alias of.
+func deprecation(f *flag.Flag) (message, alias string, deprecated bool) {
+	switch v := f.Value.(type) {
+	case *aliasValue:
+		return v.message, v.target, true
+	case *deprecatedValue:
+		return v.message, "", true
+	}
+	return "", "", false
+}
+
+// deprecationNote returns note about flag deprecation to be printed within
+// usage. Names of the alias target flag are obtained by names.
+func deprecationNote(flags *flag.FlagSet, names func(*flag.Flag) []string, f *flag.Flag) (string, bool) {
+	message, alias, deprecated := deprecation(f)
+	if !deprecated {
+		return "", false
+	}
+	if alias != "" {
+		if t := flags.Lookup(alias); t != nil {
+			if ns := names(t); len(ns) > 0 {
+				alias = ns[0]
+			}
+		}
+		return "deprecated, use " + alias, true
+	}
+	if message == "" {
+		return "deprecated", true
+	}
+	return "deprecated: " + message, true
+}
+
+func isAlias(f *flag.Flag) bool {
+	_, ok := f.Value.(*aliasValue)
+	return ok
+}
+
+type aliasValue struct {
+	fs      *flag.FlagSet
+	name    string
+	target  string
+	message string
+}
+
+func (a *aliasValue) AliasOf() string {
+	return a.target
+}
+
+func (a *aliasValue) Set(s string) error {
+	DeprecationWarning(a.fs, a.name, a.message)
+	return a.fs.Set(a.target, s)
+}
+
+func (a *aliasValue) String() string {
+	if f := a.lookup(); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+func (a *aliasValue) Get() interface{} {
+	if f := a.lookup(); f != nil {
+		if g, ok := f.Value.(flag.Getter); ok {
+			return g.Get()
+		}
+	}
+	return nil
+}
+
+func (a *aliasValue) IsBoolFlag() bool {
+	f := a.lookup()
+	return f != nil && isBoolFlag(f)
+}
+
+func (a *aliasValue) lookup() *flag.Flag {
+	if a == nil || a.fs == nil {
+		// Zero value created by flag package to check for default value.
+		return nil
+	}
+	return a.fs.Lookup(a.target)
+}
+
+type deprecatedValue struct {
+	flag.Value
+	fs      *flag.FlagSet
+	name    string
+	message string
+}
+
+func (d *deprecatedValue) Set(s string) error {
+	DeprecationWarning(d.fs, d.name, d.message)
+	return d.Value.Set(s)
+}
+
+func (d *deprecatedValue) String() string {
+	if d == nil || d.Value == nil {
+		return ""
+	}
+	return d.Value.String()
+}
+
+func (d *deprecatedValue) Get() interface{} {
+	if g, ok := d.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return nil
+}
+
+func (d *deprecatedValue) IsBoolFlag() bool {
+	return isBoolValue(d.Value)
+}
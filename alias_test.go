@@ -0,0 +1,190 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+)
+
+// visitParser sets values of unspecified flags from the map like env.Parser
+// does.
+type visitParser map[string]string
+
+func (p visitParser) Parse(_ context.Context, fs parse.FlagSet) (err error) {
+	fs.VisitUnspecified(func(f *flag.Flag) {
+		if v, has := p[f.Name]; has && err == nil {
+			err = f.Value.Set(v)
+		}
+	})
+	return err
+}
+
+func TestAlias(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		parsers []Parser
+		exp     int
+		warning string
+	}{
+		{
+			name: "alias",
+			parsers: []Parser{
+				setParser("listen-port", "1"),
+			},
+			exp:     1,
+			warning: "flag \"listen-port\" is deprecated: use \"port\" instead\n",
+		},
+		{
+			name: "target first",
+			parsers: []Parser{
+				setParser("port", "1"),
+				setParser("listen-port", "2"),
+				visitParser{"listen-port": "3"},
+			},
+			exp: 1,
+		},
+		{
+			name: "alias first",
+			parsers: []Parser{
+				visitParser{"listen-port": "1"},
+				setParser("port", "2"),
+				visitParser{"port": "3"},
+			},
+			exp:     1,
+			warning: "flag \"listen-port\" is deprecated: use \"port\" instead\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+			fs.SetOutput(&buf)
+			port := fs.Int("port", 0, "port to bind to")
+			Alias(fs, "listen-port", "port")
+
+			opts := make([]ParseOption, len(test.parsers))
+			for i, p := range test.parsers {
+				opts[i] = WithParser(p)
+			}
+			if err := Parse(context.Background(), fs, opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act := *port; act != test.exp {
+				t.Errorf("unexpected port: %d; want %d", act, test.exp)
+			}
+			if act := buf.String(); act != test.warning {
+				t.Errorf("unexpected warning:\n%s", cmp.Diff(test.warning, act))
+			}
+		})
+	}
+}
+
+func TestDeprecatePrintDefaults(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts []ParseOption
+		exp  string
+	}{
+		{
+			name: "aliases",
+			exp: "" +
+				"  --listen-port\n" +
+				"    \tint\n" +
+				"    \tdeprecated, use --port\n" +
+				"\n" +
+				"  --port\n" +
+				"    \tint\n" +
+				"    \tport to bind to (default 0)\n" +
+				"\n" +
+				"  --verbose\n" +
+				"    \tbool\n" +
+				"    \tverbose output (deprecated: use logging options)\n" +
+				"\n",
+		},
+		{
+			name: "hidden aliases",
+			opts: []ParseOption{
+				WithHideAliases(),
+			},
+			exp: "" +
+				"  --port\n" +
+				"    \tint\n" +
+				"    \tport to bind to (default 0)\n" +
+				"\n" +
+				"  --verbose\n" +
+				"    \tbool\n" +
+				"    \tverbose output (deprecated: use logging options)\n" +
+				"\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+			fs.SetOutput(&buf)
+			fs.Int("port", 0, "port to bind to")
+			fs.Bool("verbose", false, "verbose output")
+			Alias(fs, "listen-port", "port")
+			Deprecate(fs, "verbose", "use logging options")
+
+			opts := append(test.opts, WithParser(&fullParser{
+				Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+					return func(f *flag.Flag, it func(string)) {
+						it("--" + f.Name)
+					}, nil
+				}),
+			}))
+			if err := PrintDefaults(context.Background(), fs, opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act := buf.String(); act != test.exp {
+				t.Errorf("unexpected usage:\n%s", cmp.Diff(test.exp, act))
+			}
+			if !isBoolFlag(fs.Lookup("verbose")) {
+				t.Errorf("deprecated bool flag is not a bool flag anymore")
+			}
+
+			buf.Reset()
+			if err := fs.Parse([]string{"-verbose"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			exp := "flag \"verbose\" is deprecated: use logging options\n"
+			if act := buf.String(); act != exp {
+				t.Errorf("unexpected warning:\n%s", cmp.Diff(exp, act))
+			}
+		})
+	}
+}
+
+func TestLinkFlagCycle(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.String("a", "", "")
+	fs.String("b", "", "")
+	fs.String("c", "", "")
+	LinkFlag(fs, "a", "b")
+	LinkFlag(fs, "b", "c")
+	LinkFlag(fs, "c", "a")
+
+	// Overwrite destination value after it was linked.
+	var c string
+	fs.Lookup("c").Value = OverrideSet(fs.Lookup("c").Value, func(s string) error {
+		c = s
+		return nil
+	})
+	if err := fs.Set("a", "x"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act, exp := c, "x"; act != exp {
+		t.Errorf("unexpected overwritten value: %q; want %q", act, exp)
+	}
+	var actual []string
+	fs.Visit(func(f *flag.Flag) {
+		actual = append(actual, f.Name)
+	})
+	if exp := []string{"a", "b", "c"}; !cmp.Equal(actual, exp) {
+		t.Errorf("unexpected actual flags:\n%s", cmp.Diff(exp, actual))
+	}
+}
@@ -0,0 +1,435 @@
+package flagutil
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Bind defines flags for each exported field of the struct pointed by v.
+//
+// Flag name, usage and default value are given by the field tags:
+//
+//	type Config struct {
+//		Port     int           `flag:"port" usage:"port to bind to" default:"4050"`
+//		Timeout  time.Duration `usage:"request timeout"`
+//		Database struct {
+//			Endpoint string `usage:"database endpoint"`
+//		}
+//	}
+//
+// If flag tag is not specified, field name converted to the kebab case is
+// used. Fields with "-" flag tag are ignored. Fields of struct type are bound
+// as a flag subset (see Subset()) described by the usage tag; embedded
+// structs are bound within the same set.
+//
+// Supported field types are strings, booleans, numbers, time.Duration, types
+// implementing flag.Value or encoding.TextUnmarshaler, slices of them and
+// maps with such keys and values. Slice items are appended on every Set()
+// call; map items are set by "key:value" pairs. Default value of a slice or a
+// map is separated by comma; it is replaced (not extended) by the first Set()
+// call.
+//
+// If default tag is not specified, the current field value is used as
+// default.
+func Bind(fs *flag.FlagSet, v interface{}) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("flagutil: bind: want pointer to struct; got %T", v)
+	}
+	return bind(fs, p.Elem())
+}
+
+var (
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+func bind(fs *flag.FlagSet, v reflect.Value) (err error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			// Unexported field. Note that exported fields of embedded
+			// unexported struct are still accessible.
+			continue
+		}
+		name := field.Tag.Get("flag")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if isNested(fv) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if field.Anonymous && name == "" {
+				err = bind(fs, fv)
+			} else {
+				if name == "" {
+					name = kebabCase(field.Name)
+				}
+				var bindErr error
+				err = Subset(fs, name, func(sub *flag.FlagSet) {
+					bindErr = bind(sub, fv)
+				}, WithSubsetDescription(field.Tag.Get("usage")))
+				if bindErr != nil {
+					err = bindErr
+				}
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = kebabCase(field.Name)
+		}
+		value, err := bindValue(fv)
+		if err != nil {
+			return fmt.Errorf("flagutil: bind field %s: %w", field.Name, err)
+		}
+		if def, has := field.Tag.Lookup("default"); has {
+			if err := setDefault(value, fv, def); err != nil {
+				return fmt.Errorf(
+					"flagutil: bind field %s: default value %q: %w",
+					field.Name, def, err,
+				)
+			}
+		}
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf("flagutil: bind field %s: flag %q redefined", field.Name, name)
+		}
+		fs.Var(value, name, field.Tag.Get("usage"))
+	}
+	return nil
+}
+
+func isNested(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	p := reflect.PtrTo(t)
+	return !p.Implements(flagValueType) && !p.Implements(textUnmarshalerType)
+}
+
+func bindValue(v reflect.Value) (flag.Value, error) {
+	if x, ok := v.Addr().Interface().(flag.Value); ok {
+		return x, nil
+	}
+	t := v.Type()
+	switch kindOf(t) {
+	case reflect.Slice:
+		if !isScalar(t.Elem()) {
+			return nil, fmt.Errorf("unsupported slice item type: %s", t.Elem())
+		}
+	case reflect.Map:
+		if !isScalar(t.Key()) || !isScalar(t.Elem()) {
+			return nil, fmt.Errorf("unsupported map type: %s", t)
+		}
+	default:
+		if !isScalar(t) {
+			return nil, fmt.Errorf("unsupported type: %s", t)
+		}
+	}
+	return &fieldValue{v: v}, nil
+}
+
+func setDefault(value flag.Value, v reflect.Value, def string) error {
+	fv, ok := value.(*fieldValue)
+	if !ok {
+		return value.Set(def)
+	}
+	switch kindOf(v.Type()) {
+	case reflect.Slice, reflect.Map:
+		fv.reset()
+	default:
+		return value.Set(def)
+	}
+	if def == "" {
+		return nil
+	}
+	for _, s := range strings.Split(def, ",") {
+		if err := fv.add(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kindOf returns kind of t. Types implementing encoding.TextUnmarshaler are
+// treated as strings.
+func kindOf(t reflect.Type) reflect.Kind {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return reflect.String
+	}
+	return t.Kind()
+}
+
+func isScalar(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case
+		reflect.String,
+		reflect.Bool,
+		reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64,
+		reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64,
+		reflect.Float32,
+		reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldValue is a flag.Value bound to the struct field.
+type fieldValue struct {
+	v reflect.Value
+
+	// changed is true if Set() was called at least once. It is used to
+	// replace default items of slices and maps on the first Set() call.
+	changed bool
+}
+
+func (f *fieldValue) Set(s string) error {
+	if !f.changed {
+		f.changed = true
+		f.reset()
+	}
+	return f.add(s)
+}
+
+// reset makes slice or map field empty.
+func (f *fieldValue) reset() {
+	switch kindOf(f.v.Type()) {
+	case reflect.Slice:
+		f.v.Set(reflect.Zero(f.v.Type()))
+	case reflect.Map:
+		f.v.Set(reflect.MakeMap(f.v.Type()))
+	}
+}
+
+func (f *fieldValue) add(s string) error {
+	switch kindOf(f.v.Type()) {
+	case reflect.Slice:
+		x := reflect.New(f.v.Type().Elem()).Elem()
+		if err := setScalar(x, s); err != nil {
+			return err
+		}
+		f.v.Set(reflect.Append(f.v, x))
+		return nil
+
+	case reflect.Map:
+		i := strings.IndexByte(s, ':')
+		if i == -1 {
+			return fmt.Errorf("malformed key:value pair: %q", s)
+		}
+		t := f.v.Type()
+		k := reflect.New(t.Key()).Elem()
+		if err := setScalar(k, s[:i]); err != nil {
+			return err
+		}
+		x := reflect.New(t.Elem()).Elem()
+		if err := setScalar(x, s[i+1:]); err != nil {
+			return err
+		}
+		if f.v.IsNil() {
+			f.v.Set(reflect.MakeMap(t))
+		}
+		f.v.SetMapIndex(k, x)
+		return nil
+
+	default:
+		return setScalar(f.v, s)
+	}
+}
+
+// Copy implements ReloadValue interface.
+func (f *fieldValue) Copy() ReloadValue {
+	v := reflect.New(f.v.Type()).Elem()
+	switch kindOf(f.v.Type()) {
+	case reflect.Slice:
+		if !f.v.IsNil() {
+			v.Set(reflect.MakeSlice(f.v.Type(), f.v.Len(), f.v.Len()))
+			reflect.Copy(v, f.v)
+		}
+	case reflect.Map:
+		if !f.v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(f.v.Type(), f.v.Len()))
+			for iter := f.v.MapRange(); iter.Next(); {
+				v.SetMapIndex(iter.Key(), iter.Value())
+			}
+		}
+	default:
+		// Scalars are always replaced by Set().
+		v.Set(f.v)
+	}
+	return &fieldValue{
+		v:       v,
+		changed: f.changed,
+	}
+}
+
+// Assign implements ReloadValue interface.
+func (f *fieldValue) Assign(v ReloadValue) {
+	x := v.(*fieldValue)
+	f.v.Set(x.v)
+	f.changed = x.changed
+}
+
+func (f *fieldValue) String() string {
+	if !f.v.IsValid() {
+		return ""
+	}
+	switch kindOf(f.v.Type()) {
+	case reflect.Slice:
+		xs := make([]string, f.v.Len())
+		for i := range xs {
+			xs[i] = formatScalar(f.v.Index(i))
+		}
+		return strings.Join(xs, ",")
+
+	case reflect.Map:
+		xs := make([]string, 0, f.v.Len())
+		for iter := f.v.MapRange(); iter.Next(); {
+			xs = append(xs, formatScalar(iter.Key())+":"+formatScalar(iter.Value()))
+		}
+		sort.Strings(xs)
+		return strings.Join(xs, ",")
+
+	default:
+		return formatScalar(f.v)
+	}
+}
+
+func (f *fieldValue) Get() interface{} {
+	if !f.v.IsValid() {
+		return nil
+	}
+	return f.v.Interface()
+}
+
+func (f *fieldValue) IsBoolFlag() bool {
+	return f.v.IsValid() && f.v.Kind() == reflect.Bool
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case
+		reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case
+		reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case
+		reflect.Float32,
+		reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type: %s", v.Type())
+	}
+	return nil
+}
+
+func formatScalar(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		p, err := m.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(p)
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			p, err := m.MarshalText()
+			if err != nil {
+				return ""
+			}
+			return string(p)
+		}
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// kebabCase converts CamelCase name into kebab-case.
+func kebabCase(s string) string {
+	rs := []rune(s)
+	var sb strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			// Insert dash at words boundaries: "fooBar" and "HTTPServer".
+			if i > 0 && (unicode.IsLower(rs[i-1]) ||
+				(i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+				sb.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,189 @@
+package flagutil
+
+import (
+	"context"
+	"flag"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse/file"
+	"github.com/gobwas/flagutil/parse/file/json"
+)
+
+type bindCommon struct {
+	Debug bool `usage:"enable debug mode"`
+}
+
+type bindConfig struct {
+	bindCommon
+
+	Port    int               `flag:"port" usage:"port to bind to" default:"4050"`
+	Timeout time.Duration     `usage:"request timeout" default:"1s"`
+	Tags    []string          `default:"a,b"`
+	Labels  map[string]int    `usage:"labels"`
+	IP      net.IP            `flag:"ip"`
+	Ratio   float64           `flag:"ratio"`
+	Ignored string            `flag:"-"`
+	Extra   map[string]string `flag:"extra"`
+
+	Database struct {
+		Endpoint string `usage:"database endpoint" default:"localhost"`
+		MaxConns uint   `default:"8"`
+	}
+
+	unexported string
+}
+
+func TestBind(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	var cfg bindConfig
+	if err := Bind(fs, &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	expNames := []string{
+		"database.endpoint",
+		"database.max-conns",
+		"debug",
+		"extra",
+		"ip",
+		"labels",
+		"port",
+		"ratio",
+		"tags",
+		"timeout",
+	}
+	if !cmp.Equal(names, expNames) {
+		t.Fatalf("unexpected flags:\n%s", cmp.Diff(expNames, names))
+	}
+	if act, exp := fs.Lookup("tags").DefValue, "a,b"; act != exp {
+		t.Errorf("unexpected default value: %q; want %q", act, exp)
+	}
+	if act, exp := inferType(fs.Lookup("timeout")), "duration"; act != exp {
+		t.Errorf("unexpected inferred type: %q; want %q", act, exp)
+	}
+
+	err := Parse(context.Background(), fs, WithParser(&file.Parser{
+		Lookup: file.BytesLookup(`{
+			"debug": true,
+			"port": 8080,
+			"timeout": "5s",
+			"tags": ["c", "d"],
+			"labels": {"x": 1},
+			"ip": "127.0.0.1",
+			"ratio": 0.5,
+			"database": {
+				"endpoint": "db:5432"
+			}
+		}`),
+		Syntax: new(json.Syntax),
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := bindConfig{
+		bindCommon: bindCommon{
+			Debug: true,
+		},
+		Port:    8080,
+		Timeout: 5 * time.Second,
+		Tags:    []string{"c", "d"},
+		Labels:  map[string]int{"x": 1},
+		IP:      net.ParseIP("127.0.0.1"),
+		Ratio:   0.5,
+	}
+	exp.Database.Endpoint = "db:5432"
+	exp.Database.MaxConns = 8
+	opts := []cmp.Option{
+		cmp.AllowUnexported(bindConfig{}),
+	}
+	if !cmp.Equal(cfg, exp, opts...) {
+		t.Errorf("unexpected config:\n%s", cmp.Diff(exp, cfg, opts...))
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		v    interface{}
+	}{
+		{
+			name: "not a pointer",
+			v:    bindConfig{},
+		},
+		{
+			name: "unsupported type",
+			v: &struct {
+				C chan int
+			}{},
+		},
+		{
+			name: "bad default",
+			v: &struct {
+				N int `default:"foo"`
+			}{},
+		},
+		{
+			name: "redefinition",
+			v: &struct {
+				A string `flag:"x"`
+				B string `flag:"x"`
+			}{},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+			if err := Bind(fs, test.v); err == nil {
+				t.Fatalf("want error; got nothing")
+			}
+		})
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for in, exp := range map[string]string{
+		"Port":       "port",
+		"MaxConns":   "max-conns",
+		"HTTPServer": "http-server",
+		"IP":         "ip",
+		"UseTLS":     "use-tls",
+	} {
+		if act := kebabCase(in); act != exp {
+			t.Errorf("kebabCase(%q) = %q; want %q", in, act, exp)
+		}
+	}
+}
+
+func TestBindDump(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	cfg := struct {
+		IP   net.IP
+		Tags []string
+	}{
+		IP:   net.ParseIP("127.0.0.1"),
+		Tags: []string{"a"},
+	}
+	if err := Bind(fs, &cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act, exp := inferType(fs.Lookup("ip")), "string"; act != exp {
+		t.Errorf("unexpected inferred type: %q; want %q", act, exp)
+	}
+	act, err := Dump(fs, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := map[string]interface{}{
+		"ip":   "127.0.0.1",
+		"tags": []interface{}{"a"},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected dump:\n%s", cmp.Diff(exp, act))
+	}
+}
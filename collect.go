@@ -0,0 +1,242 @@
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// WithCollectErrors makes Parse() to not stop on the first error, but to
+// continue through all parsers, required flags check and validation, and to
+// report all errors occurred as ParseErrors.
+//
+// Note that errors of setting flag values do not stop parsers in this mode,
+// so parsers continue with the rest of their values. Errors of file.Parser
+// in strict mode are reported as is: each file.Error becomes a ParseError
+// holding it, so positions of the keys are kept.
+func WithCollectErrors() ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.collectErrors = true
+	})
+}
+
+// ParseError describes a single error collected by Parse() called with
+// WithCollectErrors() option.
+type ParseError struct {
+	// Name is a name of the flag. It is empty if error is not related to a
+	// particular flag, e.g. when parser failed to read its source.
+	Name string
+
+	// Value is a raw value of the flag given by parser.
+	Value string
+
+	// Naming is a name the value was given under, such as "$APP_PORT" or
+	// "-p". It is reported by the parser (see parse.SetNaming()). For parsers
+	// which don't report it, it is the first name given by the parser
+	// implementing Printer interface, or is equal to Name otherwise.
+	Naming string
+
+	// Source is a source of the value. Source.Parser is nil if error is not
+	// related to a particular parser.
+	Source Source
+
+	// Err is an underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Name == "" {
+		if e.Source.Parser == nil {
+			return e.Err.Error()
+		}
+		return e.Source.String() + ": " + e.Err.Error()
+	}
+	return fmt.Sprintf(
+		"%s=%q (flag %q from %s): %v",
+		e.Naming, e.Value, e.Name, e.Source, e.Err,
+	)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors holds all errors collected by Parse() called with
+// WithCollectErrors() option.
+type ParseErrors []*ParseError
+
+func (es ParseErrors) Error() string {
+	if len(es) == 1 {
+		return es[0].Error()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d errors occurred:", len(es))
+	for _, e := range es {
+		sb.WriteString("\n  ")
+		sb.WriteString(e.Error())
+	}
+	return sb.String()
+}
+
+// collector collects errors occurred during parseFlags(). Nil collector
+// doesn't collect anything.
+type collector struct {
+	ctx   context.Context
+	c     *config
+	flags *flag.FlagSet
+	fs    parse.FlagSet
+	errs  ParseErrors
+
+	// drained is a number of set errors already taken from fs.
+	drained int
+	names   []func(*flag.Flag, func(string))
+}
+
+func newCollector(ctx context.Context, c *config, flags *flag.FlagSet, fs parse.FlagSet) *collector {
+	if !c.collectErrors {
+		return nil
+	}
+	parse.CollectErrors(fs, true)
+	return &collector{
+		ctx:   ctx,
+		c:     c,
+		flags: flags,
+		fs:    fs,
+	}
+}
+
+// catch records err returned by the i-th parser (or by no parser if i is
+// negative) along with errors of setting flag values. It returns err as is if
+// x is nil or err is a help request.
+func (x *collector) catch(i int, err error) error {
+	if x == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	for _, e := range parse.Errors(x.fs)[x.drained:] {
+		x.drained++
+		p, _ := e.Source.Parser.(Parser)
+		err := e.Err
+		if u := errors.Unwrap(err); u != nil {
+			// Strip the "set <name>" prefix.
+			err = u
+		}
+		naming := e.Naming
+		if naming == "" {
+			naming = x.naming(i, e.Name)
+		}
+		x.errs = append(x.errs, &ParseError{
+			Name:   e.Name,
+			Value:  e.Value,
+			Naming: naming,
+			Source: Source{
+				Parser:   p,
+				Location: e.Source.Location,
+			},
+			Err: err,
+		})
+	}
+	var (
+		verrs ValidationErrors
+		ferrs file.Errors
+		src   Source
+	)
+	if i >= 0 {
+		src.Parser = x.c.parsers[i].Parser
+	}
+	switch {
+	case err == nil:
+	case errors.As(err, &verrs):
+		for _, e := range verrs {
+			x.errs = append(x.errs, &ParseError{
+				Name:   e.Name,
+				Value:  e.Value,
+				Naming: x.sourceNaming(e.Name, e.Source),
+				Source: e.Source,
+				Err:    e.Err,
+			})
+		}
+	case errors.As(err, &ferrs):
+		for _, e := range ferrs {
+			pe := &ParseError{
+				Value:  e.Value,
+				Naming: e.Key,
+				Source: Source{
+					Parser:   src.Parser,
+					Location: e.Path,
+				},
+				Err: e,
+			}
+			if x.flags.Lookup(e.Key) != nil {
+				pe.Name = e.Key
+			}
+			x.errs = append(x.errs, pe)
+		}
+	default:
+		x.errs = append(x.errs, &ParseError{
+			Source: src,
+			Err:    err,
+		})
+	}
+	return nil
+}
+
+// naming returns the first name given to the flag by the i-th parser. It is
+// used for parsers which don't report naming of the values.
+func (x *collector) naming(i int, name string) string {
+	f := x.flags.Lookup(name)
+	if i < 0 || f == nil {
+		return name
+	}
+	if x.names == nil {
+		names, err := parserNames(x.ctx, x.c, x.flags)
+		if err != nil {
+			return name
+		}
+		x.names = names
+	}
+	fn := x.names[i]
+	if fn == nil {
+		return name
+	}
+	var naming string
+	fn(f, func(s string) {
+		if naming == "" {
+			naming = s
+		}
+	})
+	if naming == "" {
+		return name
+	}
+	return naming
+}
+
+// sourceNaming returns a name the value of the flag with given name was given
+// under by the parser of src. It is equal to name if flag value was not
+// provided by any parser.
+func (x *collector) sourceNaming(name string, src Source) string {
+	if naming, has := parse.LookupNaming(x.fs, name); has {
+		return naming
+	}
+	if src.Parser == nil {
+		return name
+	}
+	for i, p := range x.c.parsers {
+		if sameValue(p.Parser, src.Parser) {
+			return x.naming(i, name)
+		}
+	}
+	return name
+}
+
+// err returns collected errors or nil if there are no errors.
+func (x *collector) err() error {
+	if x == nil || len(x.errs) == 0 {
+		return nil
+	}
+	return x.errs
+}
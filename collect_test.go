@@ -0,0 +1,155 @@
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+	"github.com/gobwas/flagutil/parse/file/json"
+)
+
+// envParser sets values of unspecified flags like env.Parser does.
+type envParser map[string]string
+
+func (p envParser) Parse(_ context.Context, fs parse.FlagSet) error {
+	fs.VisitUnspecified(func(f *flag.Flag) {
+		name := p.name(f)
+		if v, has := p[name]; has {
+			parse.SetLocation(fs, "$"+name)
+			if err := f.Value.Set(v); err != nil {
+				panic("error must be collected")
+			}
+		}
+	})
+	return nil
+}
+
+func (p envParser) Name(context.Context, parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+	return func(f *flag.Flag, it func(string)) {
+		it("$" + p.name(f))
+	}, nil
+}
+
+func (p envParser) name(f *flag.Flag) string {
+	return "APP_" + strings.ToUpper(f.Name)
+}
+
+func TestParseCollectErrors(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.Duration("timeout", 0, "")
+	fs.Int("workers", 0, "")
+	fs.String("name", "", "")
+	host := fs.String("host", "", "")
+
+	err := Parse(context.Background(), fs,
+		WithParser(envParser{
+			"APP_PORT":    "80a",
+			"APP_TIMEOUT": "1 second",
+			"APP_HOST":    "localhost",
+		}),
+		WithParser(setParser(
+			"workers", "-1",
+			"undefined", "1",
+		)),
+		WithParser(ParserFunc(func(context.Context, parse.FlagSet) error {
+			return fmt.Errorf("source not found")
+		})),
+		WithRequired("name"),
+		WithValidator("host", func(v flag.Value) error {
+			return fmt.Errorf("not resolvable")
+		}),
+		WithValidator("workers", func(v flag.Value) error {
+			if strings.HasPrefix(v.String(), "-") {
+				return fmt.Errorf("must be positive")
+			}
+			return nil
+		}),
+		WithCollectErrors(),
+	)
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want ParseErrors; got %v", err)
+	}
+	if *host != "localhost" {
+		t.Errorf("valid values must be set")
+	}
+	var act []string
+	for _, e := range errs {
+		act = append(act, fmt.Sprintf(
+			"%s %s %q %T %s",
+			e.Name, e.Naming, e.Value, e.Source.Parser, e.Source.Location,
+		))
+	}
+	exp := []string{
+		`port $APP_PORT "80a" flagutil.envParser $APP_PORT`,
+		`timeout $APP_TIMEOUT "1 second" flagutil.envParser $APP_TIMEOUT`,
+		`undefined undefined "1" flagutil.ParserFunc `,
+		`  "" flagutil.ParserFunc `,
+		`  "" <nil> `,
+		`host $APP_HOST "localhost" flagutil.envParser $APP_HOST`,
+		`workers workers "-1" flagutil.ParserFunc `,
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected errors:\n%s", cmp.Diff(exp, act))
+	}
+	if !errors.Is(errs[2], parse.ErrUndefined) {
+		t.Errorf("want error matching parse.ErrUndefined; got %v", errs[2])
+	}
+	msg := err.Error()
+	for _, s := range []string{
+		"7 errors occurred:",
+		`$APP_PORT="80a" (flag "port" from flagutil.envParser ($APP_PORT)): `,
+		"flagutil.ParserFunc: source not found",
+		"required flags are not specified",
+		`$APP_HOST="localhost" (flag "host" from flagutil.envParser ($APP_HOST)): not resolvable`,
+		`workers="-1" (flag "workers" from flagutil.ParserFunc): must be positive`,
+	} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("want error message containing %q; got:\n%s", s, msg)
+		}
+	}
+}
+
+func TestParseCollectErrorsStrictFile(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	host := fs.String("host", "", "")
+
+	err := Parse(context.Background(), fs,
+		WithParser(&file.Parser{
+			Lookup: file.BytesLookup("{\n\"port\": \"x\",\n\"hots\": \"a\",\n\"host\": \"b\"\n}"),
+			Syntax: new(json.Syntax),
+			Strict: true,
+		}),
+		WithCollectErrors(),
+	)
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want ParseErrors; got %v", err)
+	}
+	if *host != "b" {
+		t.Errorf("valid values must be set")
+	}
+	var act []string
+	for _, e := range errs {
+		act = append(act, e.Name+" "+e.Err.Error())
+	}
+	exp := []string{
+		`port 2:1: invalid value "x" of key "port": parse error`,
+		` 3:1: unknown key "hots"`,
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected errors:\n%s", cmp.Diff(exp, act))
+	}
+	if !errors.Is(errs[1], parse.ErrUndefined) {
+		t.Errorf("want error matching parse.ErrUndefined; got %v", errs[1])
+	}
+}
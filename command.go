@@ -0,0 +1,220 @@
+package flagutil
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Command represents a node of commands tree. Each command owns its flag set
+// and parsing pipeline.
+//
+// Command's pipeline must contain a program arguments parser (such as
+// pargs.Parser or args.Parser) to make subcommands dispatch possible: the
+// first argument left unparsed by it is treated as a subcommand name.
+type Command struct {
+	// Name is a name of the command used to dispatch it from the parent.
+	Name string
+
+	// Usage is a short description of the command printed within parent's
+	// usage message.
+	Usage string
+
+	// Flags holds command specific flags.
+	// If Flags is nil, an empty flag set is created on demand.
+	Flags *flag.FlagSet
+
+	// Persistent holds flags which are defined for the command and for all
+	// of its descendants. Once persistent flag is set by some command's
+	// pipeline, descendant pipelines treat it as already specified.
+	//
+	// Execute() panics if persistent flag name collides with the name of
+	// command's own flag or the persistent flag of its ancestors.
+	Persistent *flag.FlagSet
+
+	// Options returns options used to parse command flags from given
+	// arguments. For example, it may return options with env.Parser which
+	// uses command specific prefix or with file.Parser which uses command
+	// specific Section.
+	//
+	// Note that parent's parsers which do not stop on the subcommand name
+	// (e.g. parsers of the configuration file) should be configured to
+	// ignore flags of the subcommands.
+	Options func(args []string) []ParseOption
+
+	// Run is called with non-flag arguments when the command is the last one
+	// in the dispatch chain.
+	Run func(ctx context.Context, args []string) error
+
+	// Commands holds subcommands of the command.
+	Commands []*Command
+}
+
+// Execute parses args according to the commands tree rooted at cmd and runs
+// the last dispatched command.
+//
+// Each command errors are handled according to its flag set ErrorHandling()
+// as Parse() does.
+func Execute(ctx context.Context, cmd *Command, args []string) error {
+	return cmd.execute(ctx, nil, args)
+}
+
+// Lookup returns subcommand with given name. It returns nil if there is no
+// such subcommand.
+func (cmd *Command) Lookup(name string) *Command {
+	for _, sub := range cmd.Commands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (cmd *Command) flagSet() *flag.FlagSet {
+	if cmd.Flags == nil {
+		cmd.Flags = flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+		// Make Parse() print usage with subcommands listed.
+		cmd.Flags.Usage = nil
+	}
+	return cmd.Flags
+}
+
+func (cmd *Command) execute(ctx context.Context, inherit *flag.FlagSet, args []string) error {
+	flags := cmd.flagSet()
+
+	persistent := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	defer forgetSubsets(persistent)
+	if inherit != nil {
+		inherit.VisitAll(func(f *flag.Flag) {
+			persistent.Var(f.Value, f.Name, f.Usage)
+			inheritSubset(persistent, inherit, f.Name)
+		})
+	}
+	if cmd.Persistent != nil {
+		cmd.Persistent.VisitAll(func(f *flag.Flag) {
+			defineFlag(cmd.Name, persistent, f)
+			inheritSubset(persistent, cmd.Persistent, f.Name)
+		})
+	}
+	persistent.VisitAll(func(f *flag.Flag) {
+		defineFlag(cmd.Name, flags, f)
+		inheritSubset(flags, persistent, f.Name)
+	})
+	if inherit != nil {
+		inherit.Visit(func(f *flag.Flag) {
+			SetActual(flags, f.Name)
+		})
+	}
+
+	var opts []ParseOption
+	if fn := cmd.Options; fn != nil {
+		opts = fn(args)
+	}
+	opts = append(opts, WithCommands(cmd.Commands...))
+
+	c := buildConfig(opts)
+	if err := parseFlags(ctx, &c, flags); err != nil {
+		return handleError(ctx, &c, flags, err)
+	}
+
+	rest := nonFlagArgs(&c)
+	if len(rest) > 0 {
+		if sub := cmd.Lookup(rest[0]); sub != nil {
+			actual := make(map[string]bool)
+			flags.Visit(func(f *flag.Flag) {
+				actual[f.Name] = true
+			})
+			persistent.VisitAll(func(f *flag.Flag) {
+				if actual[f.Name] {
+					SetActual(persistent, f.Name)
+				}
+			})
+			return sub.execute(ctx, persistent, rest[1:])
+		}
+	}
+	if cmd.Run != nil {
+		return cmd.Run(ctx, rest)
+	}
+	if len(cmd.Commands) == 0 {
+		return nil
+	}
+	var err error
+	if len(rest) == 0 {
+		err = fmt.Errorf("command is required")
+	} else {
+		err = fmt.Errorf("unknown command: %q", rest[0])
+	}
+	return handleError(ctx, &c, flags, err)
+}
+
+// defineFlag defines flag f within fs. It does nothing if fs already holds
+// the same flag (e.g. when command is executed multiple times). It panics if
+// fs holds a different flag with the same name.
+func defineFlag(cmd string, fs *flag.FlagSet, f *flag.Flag) {
+	prev := fs.Lookup(f.Name)
+	if prev == nil {
+		fs.Var(f.Value, f.Name, f.Usage)
+		return
+	}
+	if !sameValue(prev.Value, f.Value) {
+		panic(fmt.Sprintf(
+			"flagutil: command %q: persistent flag redefined: %s",
+			cmd, f.Name,
+		))
+	}
+}
+
+// sameValue reports whether v0 and v1 are the same value. Values of
+// non-comparable types (such as maps) are compared by identity of the data
+// they refer to.
+func sameValue(v0, v1 interface{}) bool {
+	t := reflect.TypeOf(v0)
+	if t != reflect.TypeOf(v1) {
+		return false
+	}
+	if t.Comparable() {
+		return v0 == v1
+	}
+	x0 := reflect.ValueOf(v0)
+	x1 := reflect.ValueOf(v1)
+	switch t.Kind() {
+	case reflect.Map:
+		return x0.Pointer() == x1.Pointer()
+	case reflect.Slice:
+		return x0.Pointer() == x1.Pointer() && x0.Len() == x1.Len()
+	}
+	return false
+}
+
+func nonFlagArgs(c *config) []string {
+	for _, p := range c.parsers {
+		switch x := p.Parser.(type) {
+		case interface{ NonOptionArgs() []string }:
+			return x.NonOptionArgs()
+		case interface{ NonFlagArgs() []string }:
+			return x.NonFlagArgs()
+		}
+	}
+	return nil
+}
+
+func printCommands(w io.Writer, cmds []*Command) {
+	if len(cmds) == 0 {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("Commands:\n")
+	for _, cmd := range cmds {
+		sb.WriteString("  ")
+		sb.WriteString(cmd.Name)
+		if cmd.Usage != "" {
+			sb.WriteString("\n    \t")
+			sb.WriteString(strings.ReplaceAll(cmd.Usage, "\n", "\n    \t"))
+		}
+		sb.WriteString("\n\n")
+	}
+	io.WriteString(w, sb.String())
+}
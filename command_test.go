@@ -0,0 +1,215 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+)
+
+// stubArgsParser is a simplified program arguments parser which accepts only
+// `--name=value` syntax.
+type stubArgsParser struct {
+	args []string
+	rest []string
+}
+
+func (p *stubArgsParser) Parse(_ context.Context, fs parse.FlagSet) error {
+	for i, arg := range p.args {
+		if !strings.HasPrefix(arg, "--") {
+			p.rest = p.args[i:]
+			return nil
+		}
+		if arg == "--help" {
+			return flag.ErrHelp
+		}
+		kv := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)
+		if err := fs.Set(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *stubArgsParser) NonFlagArgs() []string {
+	return p.rest
+}
+
+func withStubArgs(args []string) []ParseOption {
+	return []ParseOption{
+		WithParser(&stubArgsParser{args: args}),
+	}
+}
+
+func TestExecute(t *testing.T) {
+	var (
+		verbose string
+		dryRun  string
+		ran     []string
+		runArgs []string
+	)
+	root := &Command{
+		Name:       "app",
+		Persistent: flag.NewFlagSet("app", flag.ContinueOnError),
+		Options:    withStubArgs,
+		Commands: []*Command{
+			{
+				Name:    "db",
+				Usage:   "database management",
+				Options: withStubArgs,
+				Commands: []*Command{
+					{
+						Name:  "migrate",
+						Flags: flag.NewFlagSet("migrate", flag.ContinueOnError),
+						Options: func(args []string) []ParseOption {
+							return append(withStubArgs(args), WithParser(
+								ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+									// Must not override value set by parent.
+									return fs.Set("verbose", "env")
+								}),
+							))
+						},
+						Run: func(_ context.Context, args []string) error {
+							ran = append(ran, "migrate")
+							runArgs = args
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+	root.Persistent.StringVar(&verbose, "verbose", "", "")
+	migrate := root.Commands[0].Commands[0]
+	migrate.Flags.StringVar(&dryRun, "dry-run", "", "")
+
+	err := Execute(context.Background(), root, []string{
+		"--verbose=cli", "db", "migrate", "--dry-run=yes", "arg",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, act := []string{"migrate"}, ran; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected run commands:\n%s", cmp.Diff(exp, act))
+	}
+	if exp, act := []string{"arg"}, runArgs; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected run args:\n%s", cmp.Diff(exp, act))
+	}
+	if verbose != "cli" {
+		t.Errorf("unexpected persistent flag value: %q", verbose)
+	}
+	if dryRun != "yes" {
+		t.Errorf("unexpected flag value: %q", dryRun)
+	}
+
+	err = Execute(context.Background(), root, []string{"db", "unknown"})
+	if err == nil {
+		t.Fatalf("want error on unknown command; got nothing")
+	}
+}
+
+func TestExecutePersistentRedefined(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		setup func(root, sub *Command)
+	}{
+		{
+			name: "inherited",
+			setup: func(root, sub *Command) {
+				sub.Persistent = flag.NewFlagSet("sub", flag.ContinueOnError)
+				sub.Persistent.String("verbose", "", "")
+			},
+		},
+		{
+			name: "own",
+			setup: func(root, sub *Command) {
+				sub.Flags = flag.NewFlagSet("sub", flag.ContinueOnError)
+				sub.Flags.String("verbose", "", "")
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sub := &Command{
+				Name:    "sub",
+				Options: withStubArgs,
+			}
+			root := &Command{
+				Name:       "app",
+				Persistent: flag.NewFlagSet("app", flag.ContinueOnError),
+				Options:    withStubArgs,
+				Commands:   []*Command{sub},
+			}
+			root.Persistent.String("verbose", "", "")
+			test.setup(root, sub)
+
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("want panic; got nothing")
+				}
+			}()
+			Execute(context.Background(), root, []string{"sub"})
+		})
+	}
+}
+
+func TestExecuteTwice(t *testing.T) {
+	labels := make(mapValue)
+	sub := &Command{
+		Name:    "sub",
+		Options: withStubArgs,
+		Run: func(context.Context, []string) error {
+			return nil
+		},
+	}
+	root := &Command{
+		Name:       "app",
+		Persistent: flag.NewFlagSet("app", flag.ContinueOnError),
+		Options:    withStubArgs,
+		Commands:   []*Command{sub},
+	}
+	root.Persistent.Var(labels, "labels", "")
+	for i := 0; i < 2; i++ {
+		err := Execute(context.Background(), root, []string{"--labels=a:b", "sub"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if act, exp := labels.String(), "a:b"; act != exp {
+		t.Errorf("unexpected labels: %q; want %q", act, exp)
+	}
+}
+
+func TestExecuteUsage(t *testing.T) {
+	var buf bytes.Buffer
+	root := &Command{
+		Name:    "app",
+		Options: withStubArgs,
+		Commands: []*Command{
+			{Name: "db", Usage: "database management"},
+			{Name: "http", Usage: "http server"},
+		},
+	}
+	root.flagSet().SetOutput(&buf)
+
+	err := Execute(context.Background(), root, []string{"--help"})
+	if err == nil {
+		t.Fatalf("want error; got nothing")
+	}
+	exp := "" +
+		"Usage of app:\n" +
+		"Commands:\n" +
+		"  db\n" +
+		"    \tdatabase management\n" +
+		"\n" +
+		"  http\n" +
+		"    \thttp server\n" +
+		"\n"
+	if act := buf.String(); act != exp {
+		t.Error(cmp.Diff(exp, act))
+	}
+}
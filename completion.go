@@ -0,0 +1,251 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// Shells supported by WriteCompletion().
+const (
+	ShellBash = "bash"
+	ShellZsh  = "zsh"
+	ShellFish = "fish"
+)
+
+// WriteCompletion writes completion script for given shell into w.
+//
+// Completed options are the names given by parsers implementing Printer
+// interface which start with dash (such as names from pargs.Parser and
+// args.Parser). Boolean flags are completed as options without argument.
+// Flags used by file lookups (see file.FlagNames()) are completed with file
+// names. Commands given by WithCommands() option are completed as the first
+// non-flag argument.
+func WriteCompletion(ctx context.Context, w io.Writer, shell string, flags *flag.FlagSet, opts ...ParseOption) error {
+	c := buildConfig(opts)
+	items, err := completionItems(ctx, &c, flags)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	name := flags.Name()
+	switch shell {
+	case ShellBash:
+		writeBashCompletion(&buf, name, items, c.commands)
+	case ShellZsh:
+		writeZshCompletion(&buf, name, items, c.commands)
+	case ShellFish:
+		writeFishCompletion(&buf, name, items, c.commands)
+	default:
+		return fmt.Errorf("flagutil: unsupported shell: %q", shell)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+type completionItem struct {
+	names []string
+	usage string
+	bool  bool
+	file  bool
+}
+
+func completionItems(ctx context.Context, c *config, flags *flag.FlagSet) ([]completionItem, error) {
+	names, err := flagNames(ctx, c, flags)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, p := range c.parsers {
+		if fp, ok := p.Parser.(*file.Parser); ok {
+			for _, name := range file.FlagNames(fp.Lookup) {
+				files[name] = true
+			}
+		}
+	}
+	var items []completionItem
+	flags.VisitAll(func(f *flag.Flag) {
+		if isAlias(f) {
+			return
+		}
+		var opts []string
+		for _, name := range names(f) {
+			if strings.HasPrefix(name, "-") {
+				opts = append(opts, name)
+			}
+		}
+		if len(opts) == 0 {
+			return
+		}
+		_, usage := unquoteUsage(c.unquoteUsageMode, f)
+		if i := strings.IndexByte(usage, '\n'); i != -1 {
+			usage = usage[:i]
+		}
+		items = append(items, completionItem{
+			names: opts,
+			usage: usage,
+			bool:  isBoolFlag(f),
+			file:  files[f.Name],
+		})
+	})
+	return items, nil
+}
+
+func writeBashCompletion(w io.Writer, name string, items []completionItem, cmds []*Command) {
+	fn := "_" + identifier(name) + "_completion"
+	var all, args []string
+	for _, item := range items {
+		all = append(all, item.names...)
+		if !item.bool {
+			args = append(args, item.names...)
+		}
+	}
+	fmt.Fprintf(w, "# bash completion for %s\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintf(w, "\tlocal cur prev\n")
+	fmt.Fprintf(w, "\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "\tprev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(w, "\tcase \"$prev\" in\n")
+	for _, item := range items {
+		if item.bool {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s)\n", strings.Join(item.names, "|"))
+		if item.file {
+			fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		} else {
+			fmt.Fprintf(w, "\t\tCOMPREPLY=()\n")
+		}
+		fmt.Fprintf(w, "\t\treturn 0\n")
+		fmt.Fprintf(w, "\t\t;;\n")
+	}
+	fmt.Fprintf(w, "\tesac\n")
+	fmt.Fprintf(w, "\tif [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(w, "\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(all, " ")))
+	fmt.Fprintf(w, "\t\treturn 0\n")
+	fmt.Fprintf(w, "\tfi\n")
+	if len(cmds) > 0 {
+		// Command name is completed only if there is no non-flag argument
+		// before the cursor.
+		fmt.Fprintf(w, "\tlocal i\n")
+		fmt.Fprintf(w, "\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+		fmt.Fprintf(w, "\t\tcase \"${COMP_WORDS[i]}\" in\n")
+		if len(args) > 0 {
+			fmt.Fprintf(w, "\t\t%s)\n", strings.Join(args, "|"))
+			fmt.Fprintf(w, "\t\t\t((i++))\n")
+			fmt.Fprintf(w, "\t\t\t;;\n")
+		}
+		fmt.Fprintf(w, "\t\t-*)\n")
+		fmt.Fprintf(w, "\t\t\t;;\n")
+		fmt.Fprintf(w, "\t\t*)\n")
+		fmt.Fprintf(w, "\t\t\treturn 0\n")
+		fmt.Fprintf(w, "\t\t\t;;\n")
+		fmt.Fprintf(w, "\t\tesac\n")
+		fmt.Fprintf(w, "\tdone\n")
+		fmt.Fprintf(w, "\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(commandNames(cmds)))
+	}
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o default -F %s %s\n", fn, name)
+}
+
+func writeZshCompletion(w io.Writer, name string, items []completionItem, cmds []*Command) {
+	fmt.Fprintf(w, "#compdef %s\n\n", name)
+	fmt.Fprintf(w, "_arguments \\\n")
+	for _, item := range items {
+		desc := "[" + zshEscape(item.usage) + "]"
+		var spec string
+		if len(item.names) == 1 {
+			spec = shellQuote(item.names[0] + desc)
+		} else {
+			spec = "" +
+				shellQuote("("+strings.Join(item.names, " ")+")") +
+				"{" + strings.Join(item.names, ",") + "}" +
+				shellQuote(desc)
+		}
+		switch {
+		case item.bool:
+		case item.file:
+			spec += shellQuote(":file:_files")
+		default:
+			spec += shellQuote(":value: ")
+		}
+		fmt.Fprintf(w, "\t%s \\\n", spec)
+	}
+	if len(cmds) > 0 {
+		fmt.Fprintf(w, "\t%s \\\n", shellQuote("1:command:("+commandNames(cmds)+")"))
+	}
+	fmt.Fprintf(w, "\t%s\n", shellQuote("*::arg:_default"))
+}
+
+func writeFishCompletion(w io.Writer, name string, items []completionItem, cmds []*Command) {
+	fmt.Fprintf(w, "# fish completion for %s\n", name)
+	for _, item := range items {
+		fmt.Fprintf(w, "complete -c %s", name)
+		for _, n := range item.names {
+			switch {
+			case strings.HasPrefix(n, "--"):
+				fmt.Fprintf(w, " -l %s", shellQuote(n[2:]))
+			case len(n) == 2:
+				fmt.Fprintf(w, " -s %s", shellQuote(n[1:]))
+			default:
+				fmt.Fprintf(w, " -o %s", shellQuote(n[1:]))
+			}
+		}
+		if item.usage != "" {
+			fmt.Fprintf(w, " -d %s", shellQuote(item.usage))
+		}
+		switch {
+		case item.bool:
+		case item.file:
+			fmt.Fprintf(w, " -r -F")
+		default:
+			fmt.Fprintf(w, " -x")
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	for _, cmd := range cmds {
+		fmt.Fprintf(w,
+			"complete -c %s -f -n __fish_use_subcommand -a %s",
+			name, shellQuote(cmd.Name),
+		)
+		if cmd.Usage != "" {
+			fmt.Fprintf(w, " -d %s", shellQuote(cmd.Usage))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func commandNames(cmds []*Command) string {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name
+	}
+	return strings.Join(names, " ")
+}
+
+// shellQuote quotes s with single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func zshEscape(s string) string {
+	return strings.NewReplacer(
+		`[`, `\[`,
+		`]`, `\]`,
+		`:`, `\:`,
+	).Replace(s)
+}
+
+func identifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,189 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func completionFlags() (*flag.FlagSet, []ParseOption) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Bool("debug", false, "enable debug mode")
+	fs.String("config", "", "path to config file")
+	fs.Int("port", 0, "port to bind to")
+
+	cli := &fullParser{
+		Parser: ParserFunc(func(context.Context, parse.FlagSet) error {
+			return nil
+		}),
+		Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+			return func(f *flag.Flag, it func(string)) {
+				if f.Name == "port" {
+					it("-p")
+				}
+				it("--" + f.Name)
+				it("$" + strings.ToUpper(f.Name))
+			}, nil
+		}),
+	}
+	return fs, []ParseOption{
+		WithParser(cli),
+		WithParser(&file.Parser{
+			Lookup: file.Layers{
+				file.PathLookup("/etc/app.conf"),
+				file.LookupFlag(fs, "config"),
+			},
+		}),
+		WithCommands(&Command{
+			Name:  "migrate",
+			Usage: "run migrations",
+		}),
+	}
+}
+
+func TestWriteCompletion(t *testing.T) {
+	for _, test := range []struct {
+		shell string
+		exp   string
+	}{
+		{
+			shell: ShellBash,
+			exp: "" +
+				"# bash completion for app\n" +
+				"_app_completion() {\n" +
+				"\tlocal cur prev\n" +
+				"\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n" +
+				"\tprev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n" +
+				"\tcase \"$prev\" in\n" +
+				"\t--config)\n" +
+				"\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n" +
+				"\t\treturn 0\n" +
+				"\t\t;;\n" +
+				"\t-p|--port)\n" +
+				"\t\tCOMPREPLY=()\n" +
+				"\t\treturn 0\n" +
+				"\t\t;;\n" +
+				"\tesac\n" +
+				"\tif [[ \"$cur\" == -* ]]; then\n" +
+				"\t\tCOMPREPLY=($(compgen -W '--config --debug -p --port' -- \"$cur\"))\n" +
+				"\t\treturn 0\n" +
+				"\tfi\n" +
+				"\tlocal i\n" +
+				"\tfor ((i = 1; i < COMP_CWORD; i++)); do\n" +
+				"\t\tcase \"${COMP_WORDS[i]}\" in\n" +
+				"\t\t--config|-p|--port)\n" +
+				"\t\t\t((i++))\n" +
+				"\t\t\t;;\n" +
+				"\t\t-*)\n" +
+				"\t\t\t;;\n" +
+				"\t\t*)\n" +
+				"\t\t\treturn 0\n" +
+				"\t\t\t;;\n" +
+				"\t\tesac\n" +
+				"\tdone\n" +
+				"\tCOMPREPLY=($(compgen -W 'migrate' -- \"$cur\"))\n" +
+				"}\n" +
+				"complete -o default -F _app_completion app\n",
+		},
+		{
+			shell: ShellZsh,
+			exp: "" +
+				"#compdef app\n" +
+				"\n" +
+				"_arguments \\\n" +
+				"\t'--config[path to config file]'':file:_files' \\\n" +
+				"\t'--debug[enable debug mode]' \\\n" +
+				"\t'(-p --port)'{-p,--port}'[port to bind to]'':value: ' \\\n" +
+				"\t'1:command:(migrate)' \\\n" +
+				"\t'*::arg:_default'\n",
+		},
+		{
+			shell: ShellFish,
+			exp: "" +
+				"# fish completion for app\n" +
+				"complete -c app -l 'config' -d 'path to config file' -r -F\n" +
+				"complete -c app -l 'debug' -d 'enable debug mode'\n" +
+				"complete -c app -s 'p' -l 'port' -d 'port to bind to' -x\n" +
+				"complete -c app -f -n __fish_use_subcommand -a 'migrate' -d 'run migrations'\n",
+		},
+	} {
+		t.Run(test.shell, func(t *testing.T) {
+			fs, opts := completionFlags()
+			var buf bytes.Buffer
+			err := WriteCompletion(context.Background(), &buf, test.shell, fs, opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act := buf.String(); act != test.exp {
+				t.Errorf("unexpected script:\n%s", cmp.Diff(test.exp, act))
+			}
+		})
+	}
+}
+
+func TestBashCompletionCommands(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not available")
+	}
+	fs, opts := completionFlags()
+	var script bytes.Buffer
+	err = WriteCompletion(context.Background(), &script, ShellBash, fs, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, test := range []struct {
+		name  string
+		words []string
+		exp   string
+	}{
+		{
+			name:  "first",
+			words: []string{"app", ""},
+			exp:   "migrate",
+		},
+		{
+			name:  "after flags",
+			words: []string{"app", "--debug", "-p", "80", "--config=app.conf", "m"},
+			exp:   "migrate",
+		},
+		{
+			name:  "after argument",
+			words: []string{"app", "--debug", "arg", ""},
+			exp:   "",
+		},
+		{
+			name:  "after command",
+			words: []string{"app", "migrate", ""},
+			exp:   "",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var words []string
+			for _, w := range test.words {
+				words = append(words, shellQuote(w))
+			}
+			cmd := exec.Command(bash, "-c", script.String()+
+				"COMP_WORDS=("+strings.Join(words, " ")+")\n"+
+				"COMP_CWORD=$((${#COMP_WORDS[@]} - 1))\n"+
+				"_app_completion\n"+
+				"echo -n \"${COMPREPLY[*]}\"\n",
+			)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("unexpected error: %v: %s", err, out)
+			}
+			if act := string(out); act != test.exp {
+				t.Errorf("unexpected completion: %q; want %q", act, test.exp)
+			}
+		})
+	}
+}
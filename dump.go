@@ -0,0 +1,201 @@
+package flagutil
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// Dump builds structured representation of the flag values. It is the
+// inverse of parse.Setup(): flag names are split by SetSeparator into nested
+// mappings; values holding slices become lists; values holding maps become
+// mappings which items are set as "key:value" pairs by parse.Setup().
+//
+// Flags for which skip returns true and flags with empty values are omitted.
+// Skip might be nil.
+//
+// Note that flag values must implement flag.Getter interface to be dumped
+// as lists, mappings or typed scalars. Otherwise flag.Value.String() result
+// is used as is.
+func Dump(flags *flag.FlagSet, skip func(*flag.Flag) bool) (map[string]interface{}, error) {
+	var (
+		root = make(map[string]interface{})
+		err  error
+	)
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		if isAlias(f) || (skip != nil && skip(f)) {
+			return
+		}
+		v, ok := dumpValue(f)
+		if !ok {
+			return
+		}
+		err = insert(root, strings.Split(f.Name, SetSeparator), v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// WriteConfig writes flag values dumped by Dump() and encoded by enc into w.
+func WriteConfig(w io.Writer, flags *flag.FlagSet, enc file.Encoder, skip func(*flag.Flag) bool) error {
+	m, err := Dump(flags, skip)
+	if err != nil {
+		return err
+	}
+	p, err := enc.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+// insert puts v into m at given path. If some path element is already
+// occupied by a flag value, the rest of path is joined back to make
+// parse.Setup() resolve it as a single name.
+func insert(m map[string]interface{}, path []string, v interface{}) error {
+	for i := 0; i < len(path)-1; i++ {
+		x, has := m[path[i]]
+		if !has {
+			next := make(map[string]interface{})
+			m[path[i]] = next
+			m = next
+			continue
+		}
+		next, ok := x.(map[string]interface{})
+		if !ok {
+			key := strings.Join(path[i:], SetSeparator)
+			return insertKey(m, key, v)
+		}
+		m = next
+	}
+	return insertKey(m, path[len(path)-1], v)
+}
+
+func insertKey(m map[string]interface{}, key string, v interface{}) error {
+	if _, has := m[key]; has {
+		return fmt.Errorf("flagutil: dump: ambiguous flag name %q", key)
+	}
+	m[key] = v
+	return nil
+}
+
+// valueMap is a mapping holding flag value items. It is a distinct type to
+// not be populated with other flags during insert().
+type valueMap map[string]interface{}
+
+func dumpValue(f *flag.Flag) (interface{}, bool) {
+	if isBoolFlag(f) {
+		b, err := strconv.ParseBool(f.Value.String())
+		if err == nil {
+			return b, true
+		}
+	}
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		s := f.Value.String()
+		return s, s != ""
+	}
+	x := g.Get()
+	switch v := x.(type) {
+	case time.Duration:
+		return v.String(), true
+	case encoding.TextMarshaler:
+		p, err := v.MarshalText()
+		if err != nil {
+			return nil, false
+		}
+		return string(p), len(p) > 0
+	}
+	v := reflect.ValueOf(x)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		s := f.Value.String()
+		return s, s != ""
+	}
+	switch v.Kind() {
+	case
+		reflect.Slice,
+		reflect.Array:
+		if v.Len() == 0 {
+			return nil, false
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = dumpScalar(v.Index(i))
+		}
+		return list, true
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil, false
+		}
+		m := make(valueMap, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			k := fmt.Sprint(iter.Key().Interface())
+			m[k] = dumpScalar(iter.Value())
+		}
+		return m, true
+
+	default:
+		x := dumpScalar(v)
+		if s, ok := x.(string); ok && s == "" {
+			return nil, false
+		}
+		return x, true
+	}
+}
+
+func dumpScalar(v reflect.Value) interface{} {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case
+		reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		return v.Int()
+	case
+		reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		return v.Uint()
+	case
+		reflect.Float32,
+		reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
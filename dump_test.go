@@ -0,0 +1,156 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse/file"
+	"github.com/gobwas/flagutil/parse/file/json"
+	"github.com/gobwas/flagutil/parse/file/toml"
+	"github.com/gobwas/flagutil/parse/file/yaml"
+)
+
+type listValue []string
+
+func (l *listValue) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+func (l *listValue) Get() interface{} {
+	return []string(*l)
+}
+
+type mapValue map[string]string
+
+func (m mapValue) Set(s string) error {
+	i := strings.IndexByte(s, ':')
+	if i == -1 {
+		return fmt.Errorf("malformed pair: %q", s)
+	}
+	m[s[:i]] = s[i+1:]
+	return nil
+}
+func (m mapValue) String() string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+":"+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+func (m mapValue) Get() interface{} {
+	return map[string]string(m)
+}
+
+func declareDumpFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "")
+	fs.String("name", "", "")
+	fs.Int("port", 0, "")
+	fs.Bool("debug", false, "")
+	fs.Duration("timeout", 0, "")
+	fs.Var(&listValue{}, "list", "")
+	fs.Var(mapValue{}, "labels", "")
+	Subset(fs, "database", func(sub *flag.FlagSet) {
+		sub.String("endpoint", "", "")
+		sub.Int("pool", 0, "")
+	})
+}
+
+func TestDump(t *testing.T) {
+	src := flag.NewFlagSet("src", flag.PanicOnError)
+	declareDumpFlags(src)
+	for _, pair := range [][2]string{
+		{"config", "/etc/app.conf"},
+		{"name", "app"},
+		{"port", "4050"},
+		{"debug", "true"},
+		{"timeout", "5s"},
+		{"list", "a"},
+		{"list", "b"},
+		{"labels", "foo:bar"},
+		{"database.endpoint", "localhost:5432"},
+		{"database.pool", "8"},
+	} {
+		mustSet(t, src, pair[0], pair[1])
+	}
+	skip := func(f *flag.Flag) bool {
+		return f.Name == "config"
+	}
+	for _, test := range []struct {
+		name   string
+		syntax interface {
+			file.Syntax
+			file.Encoder
+		}
+	}{
+		{"json", new(json.Syntax)},
+		{"yaml", new(yaml.Syntax)},
+		{"toml", new(toml.Syntax)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteConfig(&buf, src, test.syntax, skip); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dst := flag.NewFlagSet("dst", flag.ContinueOnError)
+			declareDumpFlags(dst)
+			err := Parse(context.Background(), dst, WithParser(&file.Parser{
+				Lookup: file.BytesLookup(buf.Bytes()),
+				Syntax: test.syntax,
+			}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, buf.String())
+			}
+			src.VisitAll(func(f *flag.Flag) {
+				exp := f.Value.String()
+				if skip(f) {
+					exp = ""
+				}
+				if act := dst.Lookup(f.Name).Value.String(); act != exp {
+					t.Errorf(
+						"unexpected flag %q value after round trip:\n%s",
+						f.Name, cmp.Diff(exp, act),
+					)
+				}
+			})
+			if t.Failed() {
+				t.Logf("dumped config:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestDumpStructure(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.PanicOnError)
+	fs.String("foo", "", "")
+	fs.String("foo.bar", "", "")
+	fs.Duration("baz.qux", time.Second, "")
+	mustSet(t, fs, "foo", "a")
+	mustSet(t, fs, "foo.bar", "b")
+
+	act, err := Dump(fs, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := map[string]interface{}{
+		"foo":     "a",
+		"foo.bar": "b",
+		"baz": map[string]interface{}{
+			"qux": "1s",
+		},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected structure:\n%s", cmp.Diff(exp, act))
+	}
+}
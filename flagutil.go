@@ -26,6 +26,14 @@ func (fn ParserFunc) Parse(ctx context.Context, fs parse.FlagSet) error {
 	return fn(ctx, fs)
 }
 
+// KindParser is an optional interface of Parser which reports a stable name
+// of the parser kind, e.g. "env" or "file". It identifies the parser within
+// flags inventory (see Inventory()).
+type KindParser interface {
+	Parser
+	Kind() string
+}
+
 type Printer interface {
 	Name(context.Context, parse.FlagSet) (func(*flag.Flag, func(string)), error)
 }
@@ -41,6 +49,7 @@ type parser struct {
 	stash               func(*flag.Flag) bool
 	ignoreUndefined     bool
 	allowResetSpecified bool
+	interpolate         bool
 }
 
 type config struct {
@@ -48,6 +57,27 @@ type config struct {
 	parserOptions    []ParserOption
 	customUsage      bool
 	unquoteUsageMode UnquoteUsageMode
+	commands         []*Command
+	sources          *Sources
+	required         func(*flag.Flag) bool
+	validators       map[string][]func(flag.Value) error
+	resolvers        map[string]Resolver
+	resolve          func(*flag.Flag) bool
+	hideAliases      bool
+	hidden           func(*flag.Flag) bool
+	showHidden       bool
+	sections         bool
+	subset           string
+	renderer         UsageRenderer
+	collectErrors    bool
+}
+
+func (c *config) isRequired(f *flag.Flag) bool {
+	return c.required != nil && c.required(f)
+}
+
+func (c *config) isHidden(f *flag.Flag) bool {
+	return c.hidden != nil && c.hidden(f)
 }
 
 func buildConfig(opts []ParseOption) config {
@@ -67,35 +97,140 @@ func buildConfig(opts []ParseOption) config {
 
 func Parse(ctx context.Context, flags *flag.FlagSet, opts ...ParseOption) (err error) {
 	c := buildConfig(opts)
+	err = parseFlags(ctx, &c, flags)
+	return handleError(ctx, &c, flags, err)
+}
 
+func parseFlags(ctx context.Context, c *config, flags *flag.FlagSet) (err error) {
 	fs := parse.NewFlagSet(flags)
-	for _, p := range c.parsers {
+	if dst := c.sources; dst != nil {
+		defer func() {
+			*dst = collectSources(fs, flags)
+		}()
+	}
+	convert := resolveFunc(ctx, c, flags)
+	if convert != nil {
+		parse.Convert(fs, convert)
+	}
+	col := newCollector(ctx, c, flags, fs)
+	var ip *interpolator
+	for i, p := range c.parsers {
 		parse.NextLevel(fs)
+		parse.SetSource(fs, p.Parser)
 		parse.Stash(fs, p.stash)
 		parse.IgnoreUndefined(fs, p.ignoreUndefined)
 		parse.AllowResetSpecified(fs, p.allowResetSpecified)
+		if p.interpolate && ip == nil {
+			ip = newInterpolator(flags, convert)
+		}
+		if ip != nil {
+			parse.Intercept(fs, ip.interceptor(i, p.interpolate))
+		}
 
-		if err = p.Parse(ctx, fs); err != nil {
-			if errors.Is(err, flag.ErrHelp) {
-				_ = printUsageMaybe(ctx, &c, flags)
-			}
-			if err != nil {
-				err = fmt.Errorf("flagutil: parse error: %w", err)
-			}
-			switch flags.ErrorHandling() {
-			case flag.ContinueOnError:
-				return err
-			case flag.ExitOnError:
-				if !errors.Is(err, flag.ErrHelp) {
-					fmt.Fprintf(flags.Output(), "%v\n", err)
-				}
-				os.Exit(2)
-			case flag.PanicOnError:
-				panic(err.Error())
-			}
+		if err = col.catch(i, p.Parse(ctx, fs)); err != nil {
+			return err
 		}
 	}
-	return nil
+	if ip != nil {
+		if err = col.catch(-1, ip.resolve()); err != nil {
+			return err
+		}
+	}
+	if err = col.catch(-1, checkRequired(ctx, c, flags)); err != nil {
+		return err
+	}
+	if err = col.catch(-1, validate(c, flags, collectSources(fs, flags))); err != nil {
+		return err
+	}
+	return col.err()
+}
+
+// Source describes where the flag value came from.
+type Source struct {
+	// Parser is a parser which provided the value.
+	Parser Parser
+
+	// Location is an optional parser specific location of the value, such as
+	// path to the configuration file or name of the environment variable.
+	Location string
+}
+
+// String returns kind of the parser (see KindParser) followed by the location
+// in parentheses. Go type of the parser is used if it doesn't report its kind.
+func (s Source) String() string {
+	if s.Parser == nil {
+		return "<unknown>"
+	}
+	var name string
+	if k, ok := s.Parser.(KindParser); ok {
+		name = k.Kind()
+	} else {
+		name = fmt.Sprintf("%T", s.Parser)
+	}
+	if s.Location != "" {
+		name += " (" + s.Location + ")"
+	}
+	return name
+}
+
+// Sources maps flag names to the sources of their values.
+// Flags which values were not provided by any parser are not present.
+type Sources map[string]Source
+
+// Lookup returns source of the flag value with given name.
+// It returns false if the flag value was not provided by any parser.
+func (s Sources) Lookup(name string) (Source, bool) {
+	src, has := s[name]
+	return src, has
+}
+
+func collectSources(fs parse.FlagSet, flags *flag.FlagSet) Sources {
+	s := make(Sources)
+	flags.VisitAll(func(f *flag.Flag) {
+		src, has := parse.LookupSource(fs, f.Name)
+		if !has {
+			return
+		}
+		p, _ := src.Parser.(Parser)
+		s[f.Name] = Source{
+			Parser:   p,
+			Location: src.Location,
+		}
+	})
+	return s
+}
+
+// handleError handles non-nil err according to flags.ErrorHandling().
+// It prints usage message if err is flag.ErrHelp.
+func handleError(ctx context.Context, c *config, flags *flag.FlagSet, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		var help *parse.HelpError
+		if errors.As(err, &help) {
+			cp := *c
+			cp.showHidden = cp.showHidden || help.All
+			cp.subset = help.Subset
+			c = &cp
+		}
+		if c.subset != "" && !hasSubset(flags, c.subset) {
+			err = fmt.Errorf("help: unknown flag subset %q", c.subset)
+		} else {
+			_ = printUsageMaybe(ctx, c, flags)
+		}
+	}
+	err = fmt.Errorf("flagutil: parse error: %w", err)
+	switch flags.ErrorHandling() {
+	case flag.ExitOnError:
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(flags.Output(), "%v\n", err)
+		}
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err.Error())
+	}
+	return err
 }
 
 // PrintDefaults prints parsers aware usage message to flags.Output().
@@ -150,75 +285,201 @@ func (m UnquoteUsageMode) has(x UnquoteUsageMode) bool {
 }
 
 func printDefaults(ctx context.Context, c *config, flags *flag.FlagSet) (err error) {
-	fs := parse.NewFlagSet(flags)
-
-	var hasNameFunc bool
-	nameFunc := make([]func(*flag.Flag, func(string)), len(c.parsers))
-	for i := len(c.parsers) - 1; i >= 0; i-- {
-		if p, ok := c.parsers[i].Parser.(Printer); ok {
-			hasNameFunc = true
-			nameFunc[i], err = p.Name(ctx, fs)
-			if err != nil {
-				return
+	names, err := flagNames(ctx, c, flags)
+	if err != nil {
+		return err
+	}
+	list := usageFlags(c, flags, names)
+	if c.renderer != nil {
+		err = c.renderer.RenderUsage(flags.Output(), flagsUsage(c, flags, names, list))
+		if err != nil {
+			return err
+		}
+	} else {
+		var regular, hidden []*flag.Flag
+		for _, f := range list {
+			if c.isHidden(f) {
+				hidden = append(hidden, f)
+			} else {
+				regular = append(regular, f)
 			}
 		}
+		printFlags(c, flags, names, regular)
+		if len(hidden) > 0 {
+			fmt.Fprintf(flags.Output(), "Advanced:\n")
+			printFlags(c, flags, names, hidden)
+		}
+	}
+	if c.subset == "" {
+		printCommands(flags.Output(), c.commands)
 	}
 
-	var buf bytes.Buffer
+	return nil
+}
+
+// usageFlags returns flags to be listed within usage message. Hidden flags
+// follow the regular ones if they must be shown.
+func usageFlags(c *config, flags *flag.FlagSet, names func(*flag.Flag) []string) []*flag.Flag {
+	var regular, hidden []*flag.Flag
 	flags.VisitAll(func(f *flag.Flag) {
-		n, _ := buf.WriteString("  ")
+		if c.hideAliases && isAlias(f) {
+			return
+		}
+		if len(names(f)) == 0 {
+			return
+		}
+		if c.subset != "" && !inSubset(flags, f, c.subset) {
+			return
+		}
+		if c.isHidden(f) {
+			hidden = append(hidden, f)
+		} else {
+			regular = append(regular, f)
+		}
+	})
+	if c.showHidden {
+		regular = append(regular, hidden...)
+	}
+	return regular
+}
+
+// printFlags prints usage of the given flags to flags.Output(). Flags are
+// grouped into sections if needed.
+func printFlags(c *config, flags *flag.FlagSet, names func(*flag.Flag) []string, list []*flag.Flag) {
+	if !c.sections && c.subset == "" {
+		for _, f := range list {
+			printFlag(c, flags, names, f, "")
+		}
+		return
+	}
+	root := buildSections(flags, list)
+	if c.subset != "" {
+		root = root.lookup(c.subset)
+	}
+	if root != nil {
+		printSection(c, flags, names, root, "")
+	}
+}
+
+// printFlag prints usage of the flag f to flags.Output(). Each line of the
+// usage is prefixed by indent.
+func printFlag(c *config, flags *flag.FlagSet, names func(*flag.Flag) []string, f *flag.Flag, indent string) {
+	u := flagUsage(c, flags, names, f)
+
+	var buf bytes.Buffer
+	buf.WriteString(indent)
+	buf.WriteString("  ")
+	buf.WriteString(strings.Join(u.Names, ", "))
+
+	tab := "\n" + indent + "    \t"
+	if len(u.Type) > 0 {
+		buf.WriteString(tab)
+		buf.WriteString(u.Type)
+	}
+	buf.WriteString(tab)
+	buf.WriteString(strings.ReplaceAll(u.Description(), "\n", tab))
+
+	buf.WriteByte('\n')
+	buf.WriteByte('\n')
+	buf.WriteTo(flags.Output())
+}
+
+// flagNames returns a function which returns names given to the flag by
+// parsers implementing Printer interface. Returned function returns nil if
+// flag was filtered out by all of the parsers.
+func flagNames(ctx context.Context, c *config, flags *flag.FlagSet) (func(*flag.Flag) []string, error) {
+	nameFunc, err := parserNames(ctx, c, flags)
+	if err != nil {
+		return nil, err
+	}
+	var hasNameFunc bool
+	for _, fn := range nameFunc {
+		if fn != nil {
+			hasNameFunc = true
+		}
+	}
+	return func(f *flag.Flag) (names []string) {
 		for i := len(c.parsers) - 1; i >= 0; i-- {
 			fn := nameFunc[i]
 			if fn == nil {
 				continue
 			}
-			if stash := c.parsers[i].stash; stash != nil && stash(f) {
-				continue
-			}
 			fn(f, func(name string) {
-				if buf.Len() > n {
-					buf.WriteString(", ")
-				}
-				buf.WriteString(name)
+				names = append(names, name)
 			})
 		}
-		if buf.Len() == n {
+		if len(names) == 0 && !hasNameFunc {
 			// No name has been given.
-			// Two cases are possible: no Printer implementation among parsers;
-			// or some parser intentionally filtered out this flag.
-			if hasNameFunc {
-				buf.Reset()
-				return
-			}
-			buf.WriteString(f.Name)
-		}
-		name, usage := unquoteUsage(c.unquoteUsageMode, f)
-		if len(name) > 0 {
-			buf.WriteString("\n    \t")
-			buf.WriteString(name)
-		}
-		value := defValue(f)
-		buf.WriteString("\n    \t")
-		if len(usage) > 0 {
-			buf.WriteString(strings.ReplaceAll(usage, "\n", "\n    \t"))
-			if len(value) > 0 {
-				buf.WriteString(" (")
-			}
+			// Two cases are possible: no Printer implementation among
+			// parsers; or some parser intentionally filtered out this flag.
+			names = append(names, f.Name)
 		}
-		if len(value) > 0 {
-			buf.WriteString("default ")
-			buf.WriteString(defValue(f))
-			if len(usage) > 0 {
-				buf.WriteString(")")
+		return names
+	}, nil
+}
+
+// parserNames returns name functions of parsers implementing Printer
+// interface; i-th function corresponds to the i-th parser in c.parsers and is
+// nil if parser is not a Printer. Returned functions give no names to flags
+// stashed for the parser.
+func parserNames(ctx context.Context, c *config, flags *flag.FlagSet) ([]func(*flag.Flag, func(string)), error) {
+	fs := parse.NewFlagSet(flags)
+	nameFunc := make([]func(*flag.Flag, func(string)), len(c.parsers))
+	for i := len(c.parsers) - 1; i >= 0; i-- {
+		p, ok := c.parsers[i].Parser.(Printer)
+		if !ok {
+			continue
+		}
+		fn, err := p.Name(ctx, fs)
+		if err != nil {
+			return nil, err
+		}
+		stash := c.parsers[i].stash
+		nameFunc[i] = func(f *flag.Flag, it func(string)) {
+			if stash != nil && stash(f) {
+				return
 			}
+			fn(f, it)
 		}
+	}
+	return nameFunc, nil
+}
 
-		buf.WriteByte('\n')
-		buf.WriteByte('\n')
-		buf.WriteTo(flags.Output())
+// checkRequired returns error if any of the required flags is not specified.
+func checkRequired(ctx context.Context, c *config, flags *flag.FlagSet) error {
+	if c.required == nil {
+		return nil
+	}
+	actual := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		actual[f.Name] = true
 	})
-
-	return nil
+	var missing []*flag.Flag
+	flags.VisitAll(func(f *flag.Flag) {
+		if !actual[f.Name] && c.isRequired(f) {
+			missing = append(missing, f)
+		}
+	})
+	if len(missing) == 0 {
+		return nil
+	}
+	names, err := flagNames(ctx, c, flags)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString("required flags are not specified:")
+	for _, f := range missing {
+		ns := names(f)
+		if len(ns) == 0 {
+			ns = []string{f.Name}
+		}
+		sb.WriteString("\n  ")
+		sb.WriteString(f.Name)
+		sb.WriteString(": ")
+		sb.WriteString(strings.Join(ns, ", "))
+	}
+	return errors.New(sb.String())
 }
 
 func defValue(f *flag.Flag) string {
@@ -330,31 +591,39 @@ func inferType(f *flag.Flag) string {
 	if isBoolFlag(f) {
 		return "bool"
 	}
+	return typeName(valueType(f.Value))
+}
 
-	var x interface{}
-	if g, ok := f.Value.(flag.Getter); ok {
+// valueType returns type of the data held by v. It returns nil if type is not
+// known.
+func valueType(v flag.Value) reflect.Type {
+	var x interface{} = v
+	if g, ok := v.(flag.Getter); ok {
 		x = g.Get()
-	} else {
-		x = f.Value
 	}
-	v := reflect.ValueOf(x)
+	return reflect.TypeOf(x)
+}
 
-repeat:
-	if !v.IsValid() {
+// typeName returns name of the type t used within usage message, flags
+// inventory and configuration schema. It returns empty string if t is not
+// known.
+func typeName(t reflect.Type) string {
+	if t == nil {
 		return ""
 	}
-	switch v.Type() {
-	case reflect.TypeOf(time.Duration(0)):
+	if t == reflect.TypeOf(time.Duration(0)) {
 		return "duration"
 	}
-	switch v.Kind() {
+	if isTextType(t) {
+		return "string"
+	}
+	switch t.Kind() {
 	case
-		reflect.Interface,
 		reflect.Ptr:
-
-		v = v.Elem()
-		goto repeat
-
+		return typeName(t.Elem())
+	case
+		reflect.Bool:
+		return "bool"
 	case
 		reflect.String:
 		return "string"
@@ -387,12 +656,35 @@ repeat:
 	return ""
 }
 
+// SubsetOption configures flag subset registered by Subset().
+type SubsetOption func(*subset)
+
+// WithSubsetDescription sets description of the subset. Description is
+// printed within the usage message when flags are grouped into sections (see
+// WithSections()).
+func WithSubsetDescription(description string) SubsetOption {
+	return func(s *subset) {
+		s.description = description
+	}
+}
+
 // Subset registers new flag subset with given prefix within given flag
 // superset. It calls setup function to let caller register needed flags within
 // created subset.
-func Subset(super *flag.FlagSet, prefix string, setup func(sub *flag.FlagSet)) (err error) {
+//
+// Subsets nested by calling Subset() within setup function are registered
+// within superset under the joined prefix.
+func Subset(super *flag.FlagSet, prefix string, setup func(sub *flag.FlagSet), opts ...SubsetOption) (err error) {
 	sub := flag.NewFlagSet(prefix, 0)
+	defer forgetSubsets(sub)
 	setup(sub)
+	var (
+		s      = &subset{prefix: prefix}
+		nested = make(map[*subset]*subset)
+	)
+	for _, opt := range opts {
+		opt(s)
+	}
 	sub.VisitAll(func(f *flag.Flag) {
 		name := prefix + "." + f.Name
 		if super.Lookup(name) != nil {
@@ -406,6 +698,7 @@ func Subset(super *flag.FlagSet, prefix string, setup func(sub *flag.FlagSet)) (
 			return
 		}
 		super.Var(f.Value, name, f.Usage)
+		setSubset(super, name, nest(lookupSubset(sub, f.Name), s, nested))
 	})
 	return
 }
@@ -449,6 +742,7 @@ func MergeInto(super *flag.FlagSet, setup func(*flag.FlagSet)) {
 		prev := super.Lookup(next.Name)
 		if prev == nil {
 			super.Var(next.Value, next.Name, next.Usage)
+			inheritSubset(super, fs, next.Name)
 			return
 		}
 		*prev = *CombineFlags(prev, next)
@@ -460,6 +754,7 @@ func MergeInto(super *flag.FlagSet, setup func(*flag.FlagSet)) {
 func Copy(dst, src *flag.FlagSet) {
 	src.VisitAll(func(f *flag.Flag) {
 		dst.Var(f.Value, f.Name, f.Usage)
+		inheritSubset(dst, src, f.Name)
 	})
 }
 
@@ -556,10 +851,18 @@ func SetActual(fs *flag.FlagSet, name string) {
 // LinkFlag links dst to be updated when src value is set.
 // It panics if any of the given names doesn't exist in fs.
 //
-// Note that it caches the both src and dst flag.Value pointers internally, so
-// it is possible to link src to dst and dst to src without infinite recursion.
-// However, if any of the src or dst flag value get overwritten after
-// LinkFlag() call, created link will not work properly anymore.
+// LinkFlag replaces the src flag value with a wrapper, which implements
+// flag.Getter and IsBoolFlag() of the original value. The dst flag is looked
+// up each time src value is set, so it is fine to overwrite dst flag value
+// after LinkFlag() call. It is possible to link src to dst and dst to src (or
+// build longer cycles) without infinite recursion; linking the same src flag
+// multiple times adds new destinations to the existing link.
+//
+// Setting the src value marks the dst flag as set within fs (see
+// SetActual()), so that further parsers treat it as specified. Note that it
+// differs from the behavior of the earlier versions of LinkFlag(), which
+// cached dst flag value at the time of the call and didn't mark dst flag as
+// set.
 func LinkFlag(fs *flag.FlagSet, src, dst string) {
 	srcFlag := fs.Lookup(src)
 	if srcFlag == nil {
@@ -568,24 +871,82 @@ func LinkFlag(fs *flag.FlagSet, src, dst string) {
 			src,
 		))
 	}
-	dstFlag := fs.Lookup(dst)
-	if dstFlag == nil {
+	if fs.Lookup(dst) == nil {
 		panic(fmt.Sprintf(
 			"flagutil: link flag: destination flag %q must exist",
 			dst,
 		))
 	}
-	var (
-		srcValue = srcFlag.Value
-		dstValue = dstFlag.Value
-	)
-	srcFlag.Value = OverrideSet(srcFlag.Value, func(s string) error {
-		err := srcValue.Set(s)
+	if v, ok := srcFlag.Value.(*linkedValue); ok {
+		v.links = append(v.links, dst)
+		return
+	}
+	srcFlag.Value = &linkedValue{
+		Value: srcFlag.Value,
+		fs:    fs,
+		name:  src,
+		links: []string{dst},
+	}
+}
+
+// linkedValue is a value of the flag linked to other flags by LinkFlag().
+type linkedValue struct {
+	flag.Value
+	fs    *flag.FlagSet
+	name  string
+	links []string
+}
+
+func (v *linkedValue) Set(s string) error {
+	return v.set(s, make(map[string]bool))
+}
+
+// set sets s to the value and linked flags. Seen holds names of flags already
+// set to break link cycles.
+func (v *linkedValue) set(s string, seen map[string]bool) error {
+	seen[v.name] = true
+	if err := v.Value.Set(s); err != nil {
+		return err
+	}
+	for _, name := range v.links {
+		if seen[name] {
+			continue
+		}
+		f := v.fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		var err error
+		if x, ok := f.Value.(*linkedValue); ok {
+			err = x.set(s, seen)
+		} else {
+			seen[name] = true
+			err = f.Value.Set(s)
+		}
 		if err != nil {
 			return err
 		}
-		return dstValue.Set(s)
-	})
+		SetActual(v.fs, name)
+	}
+	return nil
+}
+
+func (v *linkedValue) String() string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+func (v *linkedValue) Get() interface{} {
+	if g, ok := v.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return nil
+}
+
+func (v *linkedValue) IsBoolFlag() bool {
+	return isBoolValue(v.Value)
 }
 
 func mergeUsage(name, s0, s1 string) string {
@@ -3,9 +3,12 @@ package flagutil
 import (
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
 	"regexp"
 	"strings"
 	"testing"
@@ -13,6 +16,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+	"github.com/gobwas/flagutil/parse/file/json"
 )
 
 func TestSetActual(t *testing.T) {
@@ -162,7 +167,7 @@ func TestUnquoteUsage(t *testing.T) {
 	}
 }
 
-func ExampleMerge() {
+func ExampleMergeInto() {
 	fs := flag.NewFlagSet("superset", flag.PanicOnError)
 	var (
 		s0 string
@@ -202,7 +207,7 @@ func ExampleMerge() {
 	// some flag usage here / another flag usage here
 }
 
-func ExampleMerge_different_types() {
+func ExampleMergeInto_different_types() {
 	fs := flag.NewFlagSet("superset", flag.PanicOnError)
 	var (
 		s string
@@ -487,6 +492,60 @@ func TestLinkFlag(t *testing.T) {
 	}
 }
 
+func TestLinkFlagActual(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.String("src", "", "")
+	fs.String("dst", "", "")
+	fs.String("other", "", "")
+	LinkFlag(fs, "src", "dst")
+
+	err := Parse(context.Background(), fs,
+		WithParser(setParser("src", "a")),
+		WithParser(setParser("dst", "b", "other", "c")),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isActual(fs, "dst") {
+		t.Errorf("want dst flag to be marked as set")
+	}
+	// The dst flag is treated as specified by the first parser.
+	if act, exp := fs.Lookup("dst").Value.String(), "a"; act != exp {
+		t.Errorf("unexpected dst value: %q; want %q", act, exp)
+	}
+	if act, exp := fs.Lookup("other").Value.String(), "c"; act != exp {
+		t.Errorf("unexpected other value: %q; want %q", act, exp)
+	}
+}
+
+func TestLinkFlagValue(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Bool("src", false, "")
+	fs.Bool("dst", false, "")
+	LinkFlag(fs, "src", "dst")
+	LinkFlag(fs, "src", "dst")
+
+	src := fs.Lookup("src")
+	if !isBoolFlag(src) {
+		t.Errorf("want src flag to be boolean")
+	}
+	var set int
+	dst := fs.Lookup("dst")
+	dst.Value = OverrideSet(dst.Value, func(s string) error {
+		set++
+		return nil
+	})
+	if err := fs.Parse([]string{"-src"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act, exp := src.Value.(flag.Getter).Get(), true; act != exp {
+		t.Errorf("unexpected src value: %v; want %v", act, exp)
+	}
+	if set != 1 {
+		t.Errorf("want overwritten dst value to be set once; got %d", set)
+	}
+}
+
 func assertEquals(t *testing.T, f *flag.Flag, exp string) {
 	if act := f.Value.String(); act != exp {
 		t.Errorf(
@@ -502,3 +561,178 @@ func stringFlag(name, def, desc string) *flag.Flag {
 	f := fs.Lookup(name)
 	return f
 }
+
+func TestParseSources(t *testing.T) {
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(`{"foo":"file","bar":"file"}`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.String("foo", "", "")
+	fs.String("bar", "", "")
+	fs.String("baz", "", "")
+
+	cli := ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+		return fs.Set("foo", "cli")
+	})
+	cfg := &file.Parser{
+		Lookup: file.PathLookup(f.Name()),
+		Syntax: new(json.Syntax),
+	}
+	var sources Sources
+	err = Parse(context.Background(), fs,
+		WithParser(cli),
+		WithParser(cfg),
+		WithSources(&sources),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := []cmp.Option{
+		cmp.Comparer(func(a, b Parser) bool {
+			return fmt.Sprintf("%p", a) == fmt.Sprintf("%p", b)
+		}),
+	}
+	exp := Sources{
+		"foo": {Parser: cli},
+		"bar": {Parser: cfg, Location: f.Name()},
+	}
+	if !cmp.Equal(sources, exp, opts...) {
+		t.Errorf("unexpected sources:\n%s", cmp.Diff(exp, sources, opts...))
+	}
+	if _, has := sources.Lookup("baz"); has {
+		t.Errorf("unexpected source of unspecified flag")
+	}
+}
+
+func TestParseRequired(t *testing.T) {
+	var buf bytes.Buffer
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.SetOutput(&buf)
+	fs.String("foo", "", "foo description")
+	fs.String("bar", "", "bar description")
+	fs.String("baz", "", "baz description")
+
+	printer := &fullParser{
+		Parser: ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+			return fs.Set("foo", "value")
+		}),
+		Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+			return func(f *flag.Flag, it func(string)) {
+				it("--" + f.Name)
+				it("$" + strings.ToUpper(f.Name))
+			}, nil
+		}),
+	}
+	opts := []ParseOption{
+		WithParser(printer),
+		WithRequired("foo", "bar"),
+		WithUnquoteUsageMode(UnquoteNothing),
+	}
+	err := Parse(context.Background(), fs, opts...)
+	if err == nil {
+		t.Fatalf("want error; got nothing")
+	}
+	if act, exp := err.Error(), ""+
+		"flagutil: parse error: required flags are not specified:\n"+
+		"  bar: --bar, $BAR"; act != exp {
+		t.Errorf("unexpected error:\n%s", cmp.Diff(exp, act))
+	}
+
+	if err := PrintDefaults(context.Background(), fs, opts...); err != nil {
+		t.Fatal(err)
+	}
+	exp := "" +
+		"  --bar, $BAR\n" +
+		"    \tbar description (required)\n" +
+		"\n" +
+		"  --baz, $BAZ\n" +
+		"    \tbaz description (default \"\")\n" +
+		"\n" +
+		"  --foo, $FOO\n" +
+		"    \tfoo description (required)\n" +
+		"\n"
+	if act := buf.String(); act != exp {
+		t.Error(cmp.Diff(exp, act))
+	}
+}
+
+func TestPrintDefaultsHidden(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		exp  string
+	}{
+		{
+			name: "help",
+			err:  flag.ErrHelp,
+			exp: "" +
+				"Usage of TestPrintDefaultsHidden/help:\n" +
+				"  --port\n" +
+				"    \tport to bind to (default 0)\n" +
+				"\n",
+		},
+		{
+			name: "help all",
+			err:  &parse.HelpError{All: true},
+			exp: "" +
+				"Usage of TestPrintDefaultsHidden/help_all:\n" +
+				"  --port\n" +
+				"    \tport to bind to (default 0)\n" +
+				"\n" +
+				"Advanced:\n" +
+				"  --tune-buffer\n" +
+				"    \tbuffer size (default 0)\n" +
+				"\n" +
+				"  --tune-workers\n" +
+				"    \tnumber of workers (default 0)\n" +
+				"\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+			fs.SetOutput(&buf)
+			port := fs.Int("port", 0, "port to bind to")
+			workers := fs.Int("tune-workers", 0, "number of workers")
+			fs.Int("tune-buffer", 0, "buffer size")
+
+			err := Parse(context.Background(), fs,
+				WithParser(&fullParser{
+					Parser: ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+						if err := fs.Set("tune-workers", "2"); err != nil {
+							return err
+						}
+						return fs.Set("port", "1")
+					}),
+					Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+						return func(f *flag.Flag, it func(string)) {
+							it("--" + f.Name)
+						}, nil
+					}),
+				}),
+				WithParser(ParserFunc(func(context.Context, parse.FlagSet) error {
+					return test.err
+				})),
+				WithCustomUsage(),
+				WithUnquoteUsageMode(UnquoteNothing),
+				WithHiddenPrefix("tune-"),
+			)
+			if !errors.Is(err, flag.ErrHelp) {
+				t.Fatalf("want help error; got %v", err)
+			}
+			if *port != 1 || *workers != 2 {
+				t.Errorf("hidden flags must be parsed as usual")
+			}
+			if act := buf.String(); act != test.exp {
+				t.Errorf("unexpected usage:\n%s", cmp.Diff(test.exp, act))
+			}
+		})
+	}
+}
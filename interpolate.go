@@ -0,0 +1,240 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gobwas/flagutil/parse"
+)
+
+// WithInterpolation makes Parse() to expand references within the flag values
+// provided by the parser. Supported references are:
+//
+//	${NAME}       value of the environment variable NAME;
+//	${flag:name}  final value of the flag with given name;
+//	$$            literal $ sign.
+//
+// Values containing references are set after all parsers are done, in order
+// of their dependencies. That is, flag reference is expanded only after the
+// referenced flag value is resolved. Reference cycles are reported as errors.
+// Deferred values are dropped if the flag is specified again by the further
+// parser (see WithResetSpecified()).
+//
+// Note that if the option is given to Parse() it enables interpolation for
+// all parsers.
+func WithInterpolation() (opt ParseOrParserOptionFunc) {
+	return ParseOrParserOptionFunc(func(c *config, p *parser) {
+		switch {
+		case c != nil:
+			c.parserOptions = append(c.parserOptions, opt)
+		case p != nil:
+			p.interpolate = true
+		}
+	})
+}
+
+// interpolator holds flag values deferred until all parsers are done.
+type interpolator struct {
+	flags   *flag.FlagSet
+	convert func(name, value string) (string, error)
+	values  map[string][]string
+	names   map[string]string
+	levels  map[string]int
+	order   []string
+}
+
+// newInterpolator creates interpolator setting values to flags. If convert
+// is non-nil, expanded values are passed through it as parse.Convert() does.
+func newInterpolator(flags *flag.FlagSet, convert func(string, string) (string, error)) *interpolator {
+	return &interpolator{
+		flags:   flags,
+		convert: convert,
+		values:  make(map[string][]string),
+		names:   make(map[string]string),
+		levels:  make(map[string]int),
+	}
+}
+
+// interceptor returns function to be used with parse.Intercept() for the
+// parser at given level. If interpolate is true, it defers values containing
+// references. Once a flag value is deferred, all further values of that flag
+// given by the same parser are deferred too to preserve their order.
+//
+// Values deferred by the previous levels are dropped when the flag is
+// specified again, so the further parser which resets specified flags takes
+// precedence. Values given to the alias and its target are treated as the
+// values of the same flag.
+func (ip *interpolator) interceptor(level int, interpolate bool) func(name, value string) (bool, error) {
+	return func(name, value string) (bool, error) {
+		key := ip.target(name)
+		_, deferred := ip.values[key]
+		if deferred && ip.levels[key] != level {
+			ip.drop(key)
+			deferred = false
+		}
+		if !interpolate || (!deferred && !strings.Contains(value, "$")) {
+			return false, nil
+		}
+		// Check syntax early to report error along with the value source.
+		_, err := expand(value, func(string) (string, error) {
+			return "", nil
+		})
+		if err != nil {
+			return false, err
+		}
+		if !deferred {
+			ip.order = append(ip.order, key)
+			ip.names[key] = name
+			ip.levels[key] = level
+		}
+		ip.values[key] = append(ip.values[key], value)
+		return true, nil
+	}
+}
+
+// target returns name of the flag which is an alias target of the flag with
+// given name (see Alias()). It returns name itself if the flag is not an
+// alias.
+func (ip *interpolator) target(name string) string {
+	for {
+		f := ip.flags.Lookup(name)
+		if f == nil {
+			return name
+		}
+		a, ok := f.Value.(parse.AliasValue)
+		if !ok {
+			return name
+		}
+		name = a.AliasOf()
+	}
+}
+
+// drop drops deferred values of the flag with given name.
+func (ip *interpolator) drop(name string) {
+	delete(ip.values, name)
+	delete(ip.names, name)
+	delete(ip.levels, name)
+	for i, n := range ip.order {
+		if n == name {
+			ip.order = append(ip.order[:i], ip.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (ip *interpolator) resolve() error {
+	for _, name := range ip.order {
+		if err := ip.visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visit resolves deferred values of the flag with given name after resolving
+// all deferred flags it references. Path holds names of the flags being
+// resolved at the moment and is used to detect reference cycles.
+func (ip *interpolator) visit(name string, path []string) error {
+	name = ip.target(name)
+	raw, deferred := ip.values[name]
+	if !deferred {
+		return nil
+	}
+	for i, n := range path {
+		if n == name {
+			return fmt.Errorf(
+				"interpolation cycle: %s",
+				strings.Join(append(path[i:len(path):len(path)], name), " -> "),
+			)
+		}
+	}
+	path = append(path, name)
+	for _, s := range raw {
+		var deps []string
+		_, _ = expand(s, func(ref string) (string, error) {
+			if dep := strings.TrimPrefix(ref, "flag:"); dep != ref {
+				deps = append(deps, dep)
+			}
+			return "", nil
+		})
+		for _, dep := range deps {
+			if err := ip.visit(dep, path); err != nil {
+				return err
+			}
+		}
+	}
+	values := make([]string, len(raw))
+	for i, s := range raw {
+		v, err := expand(s, ip.lookup)
+		if err != nil {
+			return fmt.Errorf("interpolate %q: %w", name, err)
+		}
+		if ip.convert != nil {
+			if v, err = ip.convert(name, v); err != nil {
+				return fmt.Errorf("set %q: %w", name, err)
+			}
+		}
+		values[i] = v
+	}
+	// Set values under the name given by the parser (which might be an
+	// alias) to warn about deprecated name usage.
+	set := ip.names[name]
+	for _, v := range values {
+		if err := ip.flags.Set(set, v); err != nil {
+			return fmt.Errorf("set %q: %w", set, err)
+		}
+	}
+	delete(ip.values, name)
+	return nil
+}
+
+func (ip *interpolator) lookup(ref string) (string, error) {
+	name := strings.TrimPrefix(ref, "flag:")
+	if name == ref {
+		return os.Getenv(ref), nil
+	}
+	f := ip.flags.Lookup(name)
+	if f == nil {
+		return "", fmt.Errorf("reference to undefined flag %q", name)
+	}
+	return f.Value.String(), nil
+}
+
+// expand expands references within s by calling fn for each of them.
+func expand(s string, fn func(ref string) (string, error)) (string, error) {
+	if strings.IndexByte(s, '$') == -1 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			sb.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end == -1 {
+				return "", fmt.Errorf("unterminated reference in %q", s)
+			}
+			ref := s[i+2 : i+2+end]
+			if ref == "" || ref == "flag:" {
+				return "", fmt.Errorf("empty reference in %q", s)
+			}
+			v, err := fn(ref)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(v)
+			i += 2 + end
+		default:
+			sb.WriteByte('$')
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,234 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+)
+
+// setParser returns a parser setting given name-value pairs.
+func setParser(pairs ...string) Parser {
+	return ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+		for i := 0; i < len(pairs); i += 2 {
+			if err := fs.Set(pairs[i], pairs[i+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func TestInterpolation(t *testing.T) {
+	const env = "FLAGUTIL_TEST_HOME"
+	os.Setenv(env, "/home/user")
+	defer os.Unsetenv(env)
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	var (
+		dataDir = fs.String("data-dir", "", "")
+		logFile = fs.String("log.file", "", "")
+		price   = fs.String("price", "", "")
+		raw     = fs.String("raw", "", "")
+		list    = new(stringSlice)
+	)
+	fs.Var(list, "list", "")
+
+	first := setParser(
+		"log.file", "${flag:data-dir}/app.log",
+		"price", "$$5",
+		"list", "a",
+		"list", "${flag:price}",
+		"list", "c",
+	)
+	second := setParser(
+		"data-dir", "${"+env+"}/.app",
+		"log.file", "override",
+	)
+	third := setParser(
+		"raw", "${"+env+"}",
+	)
+	var sources Sources
+	err := Parse(context.Background(), fs,
+		WithParser(first, WithInterpolation()),
+		WithParser(second, WithInterpolation()),
+		WithParser(third),
+		WithSources(&sources),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	act := []string{*dataDir, *logFile, *price, *raw, strings.Join(*list, ",")}
+	exp := []string{"/home/user/.app", "/home/user/.app/app.log", "$5", "${" + env + "}", "a,$5,c"}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected values:\n%s", cmp.Diff(exp, act))
+	}
+	if src := sources["log.file"]; fmt.Sprintf("%p", src.Parser) != fmt.Sprintf("%p", first) {
+		t.Errorf("unexpected source of interpolated flag: %s", src)
+	}
+}
+
+func TestInterpolationResetSpecified(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	var (
+		dir  = fs.String("dir", "", "")
+		file = fs.String("file", "", "")
+		list = new(stringSlice)
+	)
+	fs.Var(list, "list", "")
+	err := Parse(context.Background(), fs,
+		WithParser(
+			setParser(
+				"dir", "${flag:file}/..",
+				"file", "/etc/app",
+				"list", "${flag:file}",
+			),
+			WithInterpolation(),
+		),
+		WithParser(
+			setParser("list", "${flag:dir}"),
+			WithInterpolation(),
+			WithResetSpecified(),
+		),
+		WithParser(
+			setParser("dir", "/var/app"),
+			WithResetSpecified(),
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	act := []string{*dir, *file, strings.Join(*list, ",")}
+	exp := []string{"/var/app", "/etc/app", "/var/app"}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected values:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestInterpolationAlias(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		parsers []Parser
+		exp     string
+		warning bool
+	}{
+		{
+			name: "alias",
+			parsers: []Parser{
+				setParser("old-dir", "${flag:file}/.."),
+			},
+			exp:     "/etc/app/..",
+			warning: true,
+		},
+		{
+			name: "target overrides alias",
+			parsers: []Parser{
+				setParser("old-dir", "${flag:file}/.."),
+				setParser("dir", "/var/app"),
+			},
+			exp: "/var/app",
+		},
+		{
+			name: "alias overrides target",
+			parsers: []Parser{
+				setParser("dir", "${flag:file}/.."),
+				setParser("old-dir", "/var/app"),
+			},
+			exp:     "/var/app",
+			warning: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+			fs.SetOutput(&buf)
+			dir := fs.String("dir", "", "")
+			fs.String("file", "/etc/app", "")
+			Alias(fs, "old-dir", "dir")
+
+			var opts []ParseOption
+			for _, p := range test.parsers {
+				opts = append(opts, WithParser(p,
+					WithInterpolation(),
+					WithResetSpecified(),
+				))
+			}
+			if err := Parse(context.Background(), fs, opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act, exp := *dir, test.exp; act != exp {
+				t.Errorf("unexpected value: %q; want %q", act, exp)
+			}
+			if act, exp := buf.Len() > 0, test.warning; act != exp {
+				t.Errorf("unexpected deprecation warning: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		pairs []string
+		err   string
+	}{
+		{
+			name: "cycle",
+			pairs: []string{
+				"a", "${flag:b}",
+				"b", "x${flag:c}",
+				"c", "${flag:a}",
+			},
+			err: "interpolation cycle: a -> b -> c -> a",
+		},
+		{
+			name: "undefined",
+			pairs: []string{
+				"a", "${flag:x}",
+			},
+			err: `reference to undefined flag "x"`,
+		},
+		{
+			name: "unterminated",
+			pairs: []string{
+				"a", "${flag:b",
+			},
+			err: "unterminated reference",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+			fs.String("a", "", "")
+			fs.String("b", "", "")
+			fs.String("c", "", "")
+			err := Parse(context.Background(), fs,
+				WithParser(setParser(test.pairs...)),
+				WithInterpolation(),
+			)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("want error containing %q; got %v", test.err, err)
+			}
+		})
+	}
+}
+
+type stringSlice []string
+
+func (s *stringSlice) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func (s *stringSlice) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
@@ -0,0 +1,140 @@
+package flagutil
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// FlagInfo describes a flag for the external tooling.
+type FlagInfo struct {
+	// Name is the name of the flag.
+	Name string `json:"name"`
+
+	// Type is the name of the flag value type inferred from its value, e.g.
+	// "int", "duration" or "list".
+	Type string `json:"type,omitempty"`
+
+	// Default is the default value of the flag as printed within usage
+	// message.
+	Default string `json:"default,omitempty"`
+
+	// Usage is the flag usage message.
+	Usage string `json:"usage,omitempty"`
+
+	Required   bool   `json:"required,omitempty"`
+	Hidden     bool   `json:"hidden,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// AliasOf is the name of the flag this flag is alias of (see Alias()).
+	AliasOf string `json:"alias_of,omitempty"`
+
+	// Subset is the prefix of the subset flag belongs to (see Subset()).
+	Subset string `json:"subset,omitempty"`
+
+	// Names holds names given to the flag by each parser.
+	Names []ParserNames `json:"names,omitempty"`
+}
+
+// ParserNames holds names given to a flag by a single parser.
+type ParserNames struct {
+	// Parser is the kind of the parser (see KindParser), e.g. "env". It is
+	// "custom" for parsers not implementing KindParser interface.
+	Parser string `json:"parser"`
+
+	// Names holds names produced by Printer implementation of the parser.
+	// For file.Parser it holds the key path within configuration file
+	// including the Section (see file.Parser.Key()).
+	Names []string `json:"names"`
+}
+
+// Inventory returns description of all flags defined in flags, including the
+// hidden ones and aliases. Names are given to the flags by parsers specified
+// in opts and are listed in the same order as within usage message.
+func Inventory(ctx context.Context, flags *flag.FlagSet, opts ...ParseOption) ([]FlagInfo, error) {
+	c := buildConfig(opts)
+	nameFunc, err := parserNames(ctx, &c, flags)
+	if err != nil {
+		return nil, err
+	}
+	names, err := flagNames(ctx, &c, flags)
+	if err != nil {
+		return nil, err
+	}
+	var infos []FlagInfo
+	flags.VisitAll(func(f *flag.Flag) {
+		info := FlagInfo{
+			Name:     f.Name,
+			Type:     inferType(f),
+			Default:  defValue(f),
+			Required: c.isRequired(f),
+			Hidden:   c.isHidden(f),
+		}
+		_, info.Usage = unquoteUsage(UnquoteQuoted, f)
+		info.Deprecated, _ = deprecationNote(flags, names, f)
+		_, info.AliasOf, _ = deprecation(f)
+		info.Subset = flagSubset(flags, f)
+		for i := len(c.parsers) - 1; i >= 0; i-- {
+			p := c.parsers[i]
+			var ns []string
+			switch {
+			case nameFunc[i] != nil:
+				nameFunc[i](f, func(name string) {
+					ns = append(ns, name)
+				})
+			case fileParser(p.Parser) != nil:
+				if p.stash == nil || !p.stash(f) {
+					ns = append(ns, fileParser(p.Parser).Key(f.Name))
+				}
+			}
+			if len(ns) == 0 {
+				continue
+			}
+			info.Names = append(info.Names, ParserNames{
+				Parser: parserKind(p.Parser),
+				Names:  ns,
+			})
+		}
+		infos = append(infos, info)
+	})
+	return infos, nil
+}
+
+// WriteInventory writes JSON encoded flags inventory (see Inventory()) into
+// w. Written document is an object with the "name" field holding name of the
+// flag set and the "flags" field holding list of flags descriptions.
+func WriteInventory(ctx context.Context, w io.Writer, flags *flag.FlagSet, opts ...ParseOption) error {
+	infos, err := Inventory(ctx, flags, opts...)
+	if err != nil {
+		return err
+	}
+	if infos == nil {
+		infos = []FlagInfo{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Name  string     `json:"name"`
+		Flags []FlagInfo `json:"flags"`
+	}{
+		Name:  flags.Name(),
+		Flags: infos,
+	})
+}
+
+func parserKind(p Parser) string {
+	if k, ok := p.(KindParser); ok {
+		return k.Kind()
+	}
+	return "custom"
+}
+
+// fileParser returns p as file.Parser. It returns nil if p is not a
+// file.Parser.
+func fileParser(p Parser) *file.Parser {
+	fp, _ := p.(*file.Parser)
+	return fp
+}
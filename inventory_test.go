@@ -0,0 +1,124 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func TestWriteInventory(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 4050, "`port` to bind to")
+	fs.Bool("debug", false, "enable debug")
+	Subset(fs, "database", func(sub *flag.FlagSet) {
+		sub.String("endpoint", "localhost", "database endpoint")
+	})
+	Alias(fs, "listen-port", "port")
+
+	var buf bytes.Buffer
+	err := WriteInventory(context.Background(), &buf, fs,
+		WithParser(envPrinter),
+		WithParser(&file.Parser{Section: "app"}, WithStashName("debug")),
+		WithRequired("database.endpoint"),
+		WithHidden("debug"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  "name": "app",
+  "flags": [
+    {
+      "name": "database.endpoint",
+      "type": "string",
+      "default": "\"localhost\"",
+      "usage": "database endpoint",
+      "required": true,
+      "subset": "database",
+      "names": [
+        {
+          "parser": "file",
+          "names": [
+            "app.database.endpoint"
+          ]
+        },
+        {
+          "parser": "custom",
+          "names": [
+            "--database.endpoint",
+            "$DATABASE_ENDPOINT"
+          ]
+        }
+      ]
+    },
+    {
+      "name": "debug",
+      "type": "bool",
+      "default": "false",
+      "usage": "enable debug",
+      "hidden": true,
+      "names": [
+        {
+          "parser": "custom",
+          "names": [
+            "--debug",
+            "$DEBUG"
+          ]
+        }
+      ]
+    },
+    {
+      "name": "listen-port",
+      "type": "int",
+      "default": "4050",
+      "deprecated": "deprecated, use --port",
+      "alias_of": "port",
+      "names": [
+        {
+          "parser": "file",
+          "names": [
+            "app.listen-port"
+          ]
+        },
+        {
+          "parser": "custom",
+          "names": [
+            "--listen-port",
+            "$LISTEN_PORT"
+          ]
+        }
+      ]
+    },
+    {
+      "name": "port",
+      "type": "int",
+      "default": "4050",
+      "usage": "port to bind to",
+      "names": [
+        {
+          "parser": "file",
+          "names": [
+            "app.port"
+          ]
+        },
+        {
+          "parser": "custom",
+          "names": [
+            "--port",
+            "$PORT"
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected inventory:\n%s", cmp.Diff(exp, act))
+	}
+}
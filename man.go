@@ -0,0 +1,278 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// ManPage contains manual page information which is not available from the
+// flag set.
+type ManPage struct {
+	// Name is a program name. If empty, flag set name is used.
+	Name string
+
+	// Section is a manual section number. If zero, 1 is used.
+	Section int
+
+	// Summary is a one-line description of the program.
+	Summary string
+
+	// Description is an optional detailed description of the program.
+	Description string
+
+	// Date, Source and Manual fill the man page header.
+	Date   string
+	Source string
+	Manual string
+}
+
+// flagDoc holds flag documentation in a form common for documentation
+// renderers.
+type flagDoc struct {
+	FlagUsage
+
+	options []string
+	env     []string
+}
+
+func flagDocs(ctx context.Context, c *config, flags *flag.FlagSet) ([]flagDoc, error) {
+	names, err := flagNames(ctx, c, flags)
+	if err != nil {
+		return nil, err
+	}
+	var docs []flagDoc
+	flags.VisitAll(func(f *flag.Flag) {
+		if c.hideAliases && isAlias(f) {
+			return
+		}
+		u := flagUsage(c, flags, names, f)
+		if len(u.Names) == 0 {
+			return
+		}
+		d := flagDoc{
+			FlagUsage: u,
+		}
+		for _, name := range u.Names {
+			if strings.HasPrefix(name, "$") {
+				d.env = append(d.env, name[1:])
+			} else {
+				d.options = append(d.options, name)
+			}
+		}
+		docs = append(docs, d)
+	})
+	return docs, nil
+}
+
+func configFiles(c *config) (paths []string) {
+	for _, p := range c.parsers {
+		if fp, ok := p.Parser.(*file.Parser); ok {
+			paths = append(paths, file.Paths(fp.Lookup)...)
+		}
+	}
+	return paths
+}
+
+// WriteManPage writes roff manual page describing flags into w.
+//
+// OPTIONS section lists flag names given by parsers implementing Printer
+// interface; names starting with "$" are listed within ENVIRONMENT section.
+// FILES section lists paths used by file.Parser parsers.
+func WriteManPage(ctx context.Context, w io.Writer, flags *flag.FlagSet, page ManPage, opts ...ParseOption) error {
+	c := buildConfig(opts)
+	docs, err := flagDocs(ctx, &c, flags)
+	if err != nil {
+		return err
+	}
+	name := page.Name
+	if name == "" {
+		name = flags.Name()
+	}
+	section := page.Section
+	if section == 0 {
+		section = 1
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, ".TH %s %d %s %s %s\n",
+		roffQuote(strings.ToUpper(name)), section,
+		roffQuote(page.Date), roffQuote(page.Source), roffQuote(page.Manual),
+	)
+	buf.WriteString(".SH NAME\n")
+	buf.WriteString(roffEscape(name))
+	if page.Summary != "" {
+		buf.WriteString(" \\- ")
+		buf.WriteString(roffEscape(page.Summary))
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&buf, "\\fB%s\\fR [\\fIOPTIONS\\fR]", roffEscape(name))
+	if len(c.commands) > 0 {
+		buf.WriteString(" \\fICOMMAND\\fR")
+	}
+	buf.WriteString(" [\\fIARGS\\fR]\n")
+
+	if page.Description != "" {
+		buf.WriteString(".SH DESCRIPTION\n")
+		buf.WriteString(roffText(page.Description))
+		buf.WriteString("\n")
+	}
+
+	var hasOptions, hasEnv bool
+	for _, d := range docs {
+		hasOptions = hasOptions || len(d.options) > 0
+		hasEnv = hasEnv || len(d.env) > 0
+	}
+	if hasOptions {
+		buf.WriteString(".SH OPTIONS\n")
+		for _, d := range docs {
+			if len(d.options) == 0 {
+				continue
+			}
+			buf.WriteString(".TP\n")
+			for i, opt := range d.options {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				fmt.Fprintf(&buf, "\\fB%s\\fR", roffEscape(opt))
+			}
+			if d.Type != "" {
+				fmt.Fprintf(&buf, " \\fI%s\\fR", roffEscape(d.Type))
+			}
+			buf.WriteString("\n")
+			buf.WriteString(roffText(d.Description()))
+			buf.WriteString("\n")
+		}
+	}
+	if len(c.commands) > 0 {
+		buf.WriteString(".SH COMMANDS\n")
+		for _, cmd := range c.commands {
+			fmt.Fprintf(&buf, ".TP\n\\fB%s\\fR\n", roffEscape(cmd.Name))
+			buf.WriteString(roffText(cmd.Usage))
+			buf.WriteString("\n")
+		}
+	}
+	if hasEnv {
+		buf.WriteString(".SH ENVIRONMENT\n")
+		for _, d := range docs {
+			if len(d.env) == 0 {
+				continue
+			}
+			buf.WriteString(".TP\n")
+			for i, env := range d.env {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				fmt.Fprintf(&buf, "\\fB%s\\fR", roffEscape(env))
+			}
+			buf.WriteString("\n")
+			buf.WriteString(roffText(d.Description()))
+			buf.WriteString("\n")
+		}
+	}
+	if paths := configFiles(&c); len(paths) > 0 {
+		buf.WriteString(".SH FILES\n")
+		for _, path := range paths {
+			fmt.Fprintf(&buf, ".TP\n\\fI%s\\fR\n", roffEscape(path))
+		}
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// WriteMarkdown writes Markdown reference table describing flags into w.
+func WriteMarkdown(ctx context.Context, w io.Writer, flags *flag.FlagSet, opts ...ParseOption) error {
+	c := buildConfig(opts)
+	docs, err := flagDocs(ctx, &c, flags)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("| Option | Environment | Type | Default | Description |\n")
+	buf.WriteString("|--------|-------------|------|---------|-------------|\n")
+	for _, d := range docs {
+		env := make([]string, len(d.env))
+		for i, name := range d.env {
+			env[i] = "$" + name
+		}
+		def := d.Default
+		if d.Required {
+			def = "required"
+		}
+		cells := []string{
+			markdownCode(d.options),
+			markdownCode(env),
+			markdownEscape(d.Type),
+			markdownCode(nonEmpty(def)),
+			markdownEscape(d.markdownUsage()),
+		}
+		buf.WriteString("| ")
+		buf.WriteString(strings.Join(cells, " | "))
+		buf.WriteString(" |\n")
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func (d flagDoc) markdownUsage() string {
+	if d.Deprecation == "" {
+		return d.Usage
+	}
+	if d.Usage == "" {
+		return d.Deprecation
+	}
+	return d.Usage + " (" + d.Deprecation + ")"
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+var roffReplacer = strings.NewReplacer(
+	`\`, `\e`,
+	`-`, `\-`,
+)
+
+func roffEscape(s string) string {
+	return roffReplacer.Replace(s)
+}
+
+// roffText escapes multiline text s to be rendered as is.
+func roffText(s string) string {
+	lines := strings.Split(roffEscape(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n.br\n")
+}
+
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(roffEscape(s), `"`, `\(dq`) + `"`
+}
+
+func markdownCode(xs []string) string {
+	cs := make([]string, len(xs))
+	for i, x := range xs {
+		cs[i] = "`" + strings.ReplaceAll(x, "|", `\|`) + "`"
+	}
+	return strings.Join(cs, ", ")
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer(
+		"|", `\|`,
+		"\n", "<br>",
+	).Replace(s)
+}
@@ -0,0 +1,96 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func docFlags() (*flag.FlagSet, []ParseOption) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Bool("debug", false, "enable debug mode")
+	fs.Int("port", 4050, "`port` to bind to")
+
+	cli := &fullParser{
+		Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+			return func(f *flag.Flag, it func(string)) {
+				it("--" + f.Name)
+			}, nil
+		}),
+	}
+	env := &fullParser{
+		Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+			return func(f *flag.Flag, it func(string)) {
+				it("$APP_" + strings.ToUpper(f.Name))
+			}, nil
+		}),
+	}
+	return fs, []ParseOption{
+		WithParser(cli),
+		WithParser(env),
+		WithParser(&file.Parser{
+			Lookup: file.PathLookup("/etc/app.conf"),
+		}),
+		WithRequired("port"),
+	}
+}
+
+func TestWriteManPage(t *testing.T) {
+	fs, opts := docFlags()
+	var buf bytes.Buffer
+	err := WriteManPage(context.Background(), &buf, fs, ManPage{
+		Summary: "example application",
+	}, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := "" +
+		".TH \"APP\" 1 \"\" \"\" \"\"\n" +
+		".SH NAME\n" +
+		"app \\- example application\n" +
+		".SH SYNOPSIS\n" +
+		"\\fBapp\\fR [\\fIOPTIONS\\fR] [\\fIARGS\\fR]\n" +
+		".SH OPTIONS\n" +
+		".TP\n" +
+		"\\fB\\-\\-debug\\fR \\fIbool\\fR\n" +
+		"enable debug mode (default false)\n" +
+		".TP\n" +
+		"\\fB\\-\\-port\\fR \\fIport\\fR\n" +
+		"port to bind to (required)\n" +
+		".SH ENVIRONMENT\n" +
+		".TP\n" +
+		"\\fBAPP_DEBUG\\fR\n" +
+		"enable debug mode (default false)\n" +
+		".TP\n" +
+		"\\fBAPP_PORT\\fR\n" +
+		"port to bind to (required)\n" +
+		".SH FILES\n" +
+		".TP\n" +
+		"\\fI/etc/app.conf\\fR\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected man page:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	fs, opts := docFlags()
+	var buf bytes.Buffer
+	if err := WriteMarkdown(context.Background(), &buf, fs, opts...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := "" +
+		"| Option | Environment | Type | Default | Description |\n" +
+		"|--------|-------------|------|---------|-------------|\n" +
+		"| `--debug` | `$APP_DEBUG` | bool | `false` | enable debug mode |\n" +
+		"| `--port` | `$APP_PORT` | port | `required` | port to bind to |\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected markdown:\n%s", cmp.Diff(exp, act))
+	}
+}
@@ -125,3 +125,129 @@ func WithUnquoteUsageMode(m UnquoteUsageMode) ParseOptionFunc {
 		c.unquoteUsageMode = m
 	})
 }
+
+// WithHideAliases makes usage message to not list flags defined by Alias().
+// By default aliases are listed with a note about their deprecation.
+func WithHideAliases() ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.hideAliases = true
+	})
+}
+
+// WithHidden makes usage message to not list flags with given names. See
+// WithHiddenFunc() for details.
+func WithHidden(names ...string) ParseOptionFunc {
+	return WithHiddenFunc(func(f *flag.Flag) bool {
+		for _, name := range names {
+			if f.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithHiddenPrefix makes usage message to not list flags which names start
+// with given prefix. See WithHiddenFunc() for details.
+func WithHiddenPrefix(prefix string) ParseOptionFunc {
+	return WithHiddenFunc(func(f *flag.Flag) bool {
+		return strings.HasPrefix(f.Name, prefix)
+	})
+}
+
+// WithHiddenRegexp makes usage message to not list flags which names match
+// given regexp. See WithHiddenFunc() for details.
+func WithHiddenRegexp(re *regexp.Regexp) ParseOptionFunc {
+	return WithHiddenFunc(func(f *flag.Flag) bool {
+		return re.MatchString(f.Name)
+	})
+}
+
+// WithHiddenFunc makes usage message to not list flags for which check
+// returns true. Unlike stashed flags, hidden flags are still parsed as usual.
+//
+// Hidden flags are listed under the "Advanced" heading when parser reports
+// request for full help (e.g. --help-all flag, see parse.HelpError) or when
+// WithShowHidden() option is given.
+func WithHiddenFunc(check func(*flag.Flag) bool) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		prev := c.hidden
+		c.hidden = func(f *flag.Flag) bool {
+			if prev != nil && prev(f) {
+				return true
+			}
+			return check(f)
+		}
+	})
+}
+
+// WithShowHidden makes usage message to list hidden flags under the
+// "Advanced" heading. It is useful when calling PrintDefaults() directly.
+func WithShowHidden() ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.showHidden = true
+	})
+}
+
+// WithSections makes usage message to group flags into sections by their
+// subset prefixes (see Subset()). Nested sections are indented.
+func WithSections() ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.sections = true
+	})
+}
+
+// WithCommands makes usage message to list given commands as subcommands of
+// the flag set being parsed.
+func WithCommands(cmds ...*Command) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.commands = append(c.commands, cmds...)
+	})
+}
+
+// WithSources makes Parse() to store sources of the flag values into dst.
+// See Sources type for details.
+func WithSources(dst *Sources) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.sources = dst
+	})
+}
+
+// WithRequired makes Parse() to fail if any of the flags with given names is
+// not specified after all parsers are done.
+func WithRequired(names ...string) ParseOptionFunc {
+	return WithRequiredFunc(func(f *flag.Flag) bool {
+		for _, name := range names {
+			if f.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithRequiredFunc makes Parse() to fail if any of the flags for which check
+// returns true is not specified after all parsers are done.
+func WithRequiredFunc(check func(*flag.Flag) bool) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		prev := c.required
+		c.required = func(f *flag.Flag) bool {
+			if prev != nil && prev(f) {
+				return true
+			}
+			return check(f)
+		}
+	})
+}
+
+// WithValidator makes Parse() to check value of the flag with given name by v
+// after all parsers are done. Parse() reports all flags which values did not
+// pass validation as ValidationErrors.
+func WithValidator(name string, v func(flag.Value) error) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		if c.validators == nil {
+			c.validators = make(map[string][]func(flag.Value) error)
+		}
+		c.validators[name] = append(c.validators[name], v)
+	})
+}
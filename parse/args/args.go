@@ -14,6 +14,7 @@ type Parser struct {
 
 	fs    parse.FlagSet
 	pos   int
+	dash  string
 	name  string
 	value string
 	err   error
@@ -22,9 +23,12 @@ type Parser struct {
 func (p *Parser) Parse(_ context.Context, fs parse.FlagSet) error {
 	p.reset(fs)
 	for p.next() {
-		if fs.Lookup(p.name) == nil && (p.name == "help" || p.name == "h") {
-			return flag.ErrHelp
+		if fs.Lookup(p.name) == nil {
+			if err := parse.Help(p.name, p.value); err != nil {
+				return err
+			}
 		}
+		parse.SetNaming(fs, p.dash+p.name)
 		if err := fs.Set(p.name, p.value); err != nil {
 			return err
 		}
@@ -45,6 +49,11 @@ func (p *Parser) Name(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, fun
 	}, nil
 }
 
+// Kind implements flagutil.KindParser interface.
+func (p *Parser) Kind() string {
+	return "args"
+}
+
 func (p *Parser) reset(fs parse.FlagSet) {
 	p.fs = fs
 	p.pos = 0
@@ -106,6 +115,7 @@ func (p *Parser) next() bool {
 		return false
 	}
 
+	p.dash = s[:minuses]
 	p.name = name
 	p.value = value
 	return true
@@ -113,7 +123,7 @@ func (p *Parser) next() bool {
 
 func (p *Parser) isBoolFlag(name string) bool {
 	f := p.fs.Lookup(name)
-	if f == nil && (name == "help" || name == "h") {
+	if f == nil && parse.Help(name, "") != nil {
 		// Special case for help message request.
 		return true
 	}
@@ -3,6 +3,8 @@ package env
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"sync"
@@ -23,6 +25,12 @@ type Parser struct {
 	ListSeparator string
 	Replace       map[string]string
 
+	// FileSuffix enables reading flag values from files. If non-empty, for
+	// each variable NAME the parser also checks NAME+FileSuffix variable
+	// (usually "_FILE"). If it is set, contents of the file it names are used
+	// as the flag value. Having both variables set is an error.
+	FileSuffix string
+
 	LookupEnvFunc func(string) (string, bool)
 
 	once     sync.Once
@@ -67,8 +75,18 @@ func (p *Parser) Parse(_ context.Context, fs parse.FlagSet) (err error) {
 	}
 	fs.VisitUnspecified(func(f *flag.Flag) {
 		name := p.name(f)
-		value, has := p.lookupEnv(name)
-		if !has {
+		value, origin, e := p.lookup(name)
+		if origin == "" {
+			return
+		}
+		parse.SetLocation(fs, "$"+origin)
+		parse.SetNaming(fs, "$"+origin)
+		if e != nil {
+			// Report error per flag, so it is collected along with the
+			// others if fs collects errors.
+			if e = parse.ReportError(fs, f.Name, e); e != nil && err == nil {
+				err = e
+			}
 			return
 		}
 		if sep := p.ListSeparator; sep != "" {
@@ -86,16 +104,59 @@ func (p *Parser) Parse(_ context.Context, fs parse.FlagSet) (err error) {
 func (p *Parser) Name(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
 	p.init()
 	return func(f *flag.Flag, it func(string)) {
-		it("$" + p.name(f))
+		name := p.name(f)
+		it("$" + name)
+		if p.FileSuffix != "" {
+			it("$" + name + p.FileSuffix)
+		}
 	}, nil
 }
 
+// Kind implements flagutil.KindParser interface.
+func (p *Parser) Kind() string {
+	return "env"
+}
+
 func (p *Parser) name(f *flag.Flag) string {
 	name := p.Prefix + strings.ToUpper(f.Name)
 	name = p.replacer.Replace(name)
 	return name
 }
 
+// lookup returns value of the variable with given name. If FileSuffix is
+// set, it also checks the variable pointing to a file. Returned origin is the
+// name of variable which provided the value. On error, origin is the name
+// of variable which caused it.
+func (p *Parser) lookup(name string) (value, origin string, err error) {
+	value, has := p.lookupEnv(name)
+	if p.FileSuffix == "" {
+		if !has {
+			return "", "", nil
+		}
+		return value, name, nil
+	}
+	fileName := name + p.FileSuffix
+	path, hasFile := p.lookupEnv(fileName)
+	switch {
+	case has && hasFile:
+		return "", fileName, fmt.Errorf(
+			"env: both $%s and $%s are set",
+			name, fileName,
+		)
+	case has:
+		return value, name, nil
+	case !hasFile:
+		return "", "", nil
+	}
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fileName, fmt.Errorf("env: read $%s file: %v", fileName, err)
+	}
+	value = strings.TrimSuffix(string(bts), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	return value, fileName, nil
+}
+
 func (p *Parser) lookupEnv(name string) (value string, has bool) {
 	if f := p.LookupEnvFunc; f != nil {
 		return f(name)
@@ -2,7 +2,11 @@ package env
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -103,3 +107,169 @@ func marshal(values testutil.Values) map[string]string {
 	fmt.Println("marshal", env)
 	return env
 }
+
+func TestEnvParserFileSuffix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(secret, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name string
+		env  map[string]string
+		exp  [][2]string
+		err  bool
+	}{
+		{
+			name: "file",
+			env: map[string]string{
+				"F_PASSWORD_FILE": secret,
+				"F_USER":          "root",
+			},
+			exp: [][2]string{
+				{"password", "s3cr3t"},
+				{"user", "root"},
+			},
+		},
+		{
+			name: "both",
+			env: map[string]string{
+				"F_PASSWORD":      "plain",
+				"F_PASSWORD_FILE": secret,
+			},
+			err: true,
+		},
+		{
+			name: "missing file",
+			env: map[string]string{
+				"F_PASSWORD_FILE": filepath.Join(dir, "missing"),
+			},
+			err: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var fs testutil.StubFlagSet
+			fs.AddFlag("password", "")
+			fs.AddFlag("user", "")
+
+			p := Parser{
+				Prefix:     "F_",
+				FileSuffix: "_FILE",
+				LookupEnvFunc: func(name string) (value string, has bool) {
+					value, has = test.env[name]
+					return
+				},
+			}
+			err := p.Parse(context.Background(), &fs)
+			if test.err {
+				if err == nil {
+					t.Fatalf("want error; got nothing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exp, act := test.exp, fs.Pairs(); !cmp.Equal(act, exp) {
+				t.Errorf(
+					"unexpected set pairs:\n%s",
+					cmp.Diff(exp, act),
+				)
+			}
+		})
+	}
+}
+
+func TestEnvParserFileSuffixName(t *testing.T) {
+	p := Parser{
+		Prefix:     "F_",
+		FileSuffix: "_FILE",
+	}
+	name, err := p.Name(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var act []string
+	name(&flag.Flag{Name: "password"}, func(s string) {
+		act = append(act, s)
+	})
+	if exp := []string{"$F_PASSWORD", "$F_PASSWORD_FILE"}; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected names:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestEnvParserAlias(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	port := fs.Int("port", 0, "")
+	flagutil.Alias(fs, "listen-port", "port")
+
+	err := flagutil.Parse(context.Background(), fs, flagutil.WithParser(&Parser{
+		Prefix: "F_",
+		LookupEnvFunc: func(name string) (string, bool) {
+			if name == "F_LISTEN_PORT" {
+				return "4050", true
+			}
+			return "", false
+		},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act, exp := *port, 4050; act != exp {
+		t.Errorf("unexpected port: %d; want %d", act, exp)
+	}
+}
+
+func TestEnvParserFileSuffixCollectErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	flags := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	password := flags.String("password", "", "")
+	token := flags.String("token", "", "")
+	user := flags.String("user", "", "")
+
+	env := map[string]string{
+		"F_PASSWORD":      "plain",
+		"F_PASSWORD_FILE": filepath.Join(dir, "password"),
+		"F_TOKEN_FILE":    filepath.Join(dir, "missing"),
+		"F_USER":          "root",
+	}
+	p := Parser{
+		Prefix:     "F_",
+		FileSuffix: "_FILE",
+		LookupEnvFunc: func(name string) (value string, has bool) {
+			value, has = env[name]
+			return
+		},
+	}
+	fs := parse.NewFlagSet(flags)
+	parse.CollectErrors(fs, true)
+	if err := p.Parse(context.Background(), fs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *password != "" || *token != "" || *user != "root" {
+		t.Errorf("unexpected values: %q %q %q", *password, *token, *user)
+	}
+	var act []string
+	for _, e := range parse.Errors(fs) {
+		act = append(act, fmt.Sprintf(
+			"%s %s %s", e.Name, e.Naming, e.Source.Location,
+		))
+	}
+	exp := []string{
+		"password $F_PASSWORD_FILE $F_PASSWORD_FILE",
+		"token $F_TOKEN_FILE $F_TOKEN_FILE",
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected errors:\n%s", cmp.Diff(exp, act))
+	}
+}
@@ -0,0 +1,273 @@
+// Package dotenv implements .env file syntax.
+//
+// Each line of the file is a KEY=VALUE pair optionally prefixed by the
+// `export` keyword. Values might be single quoted (taken literally), double
+// quoted (with backslash escapes) or unquoted. Quoted values might span
+// multiple lines. Lines starting with # are comments; unquoted values and
+// closing quotes might be followed by a comment as well.
+//
+// Keys are mapped to flag names by reversing the rules of env.Parser: prefix
+// is stripped, SetSeparator is replaced by flagutil.SetSeparator and Replace
+// substitutions are applied in reverse.
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gobwas/flagutil"
+	"github.com/gobwas/flagutil/parse/env"
+)
+
+type Syntax struct {
+	// Prefix is a prefix of keys to be used. Keys without Prefix are ignored.
+	Prefix string
+
+	// SetSeparator is a separator of flag subsets names within key.
+	// If empty, env.DefaultSetSeparator is used.
+	SetSeparator string
+
+	// Replace contains substitutions made by env.Parser.
+	// If nil, env.DefaultReplace is used.
+	Replace map[string]string
+
+	once     sync.Once
+	replacer *strings.Replacer
+}
+
+func (s *Syntax) init() {
+	s.once.Do(func() {
+		replace := s.Replace
+		if replace == nil {
+			replace = env.DefaultReplace
+		}
+		var oldnew []string
+		for old, new := range replace {
+			oldnew = append(oldnew, strings.ToLower(new), old)
+		}
+		s.replacer = strings.NewReplacer(oldnew...)
+	})
+}
+
+// EmptyValues implements file.EmptyValueSyntax interface.
+// Lines such as `KEY=` set flags to empty strings.
+func (s *Syntax) EmptyValues() bool {
+	return true
+}
+
+func (s *Syntax) Unmarshal(p []byte) (map[string]interface{}, error) {
+	s.init()
+	pairs, err := readPairs(string(p))
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		name, ok := s.name(pair[0])
+		if !ok {
+			continue
+		}
+		m[name] = pair[1]
+	}
+	return m, nil
+}
+
+func (s *Syntax) name(key string) (string, bool) {
+	if !strings.HasPrefix(key, s.Prefix) {
+		return "", false
+	}
+	key = strings.ToLower(key[len(s.Prefix):])
+	sep := s.SetSeparator
+	if sep == "" {
+		sep = env.DefaultSetSeparator
+	}
+	parts := strings.Split(key, strings.ToLower(sep))
+	for i, part := range parts {
+		parts[i] = s.replacer.Replace(part)
+	}
+	return strings.Join(parts, flagutil.SetSeparator), true
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func readPairs(src string) (pairs [][2]string, err error) {
+	p := parser{
+		src:  src,
+		line: 1,
+	}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return pairs, nil
+		}
+		switch p.peek() {
+		case '\n':
+			p.next()
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+		key, value, err := p.pair()
+		if err != nil {
+			return nil, fmt.Errorf("dotenv: line %d: %v", p.line, err)
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+}
+
+func (p *parser) pair() (key, value string, err error) {
+	key = p.key()
+	if key == "export" && !p.eof() && isSpace(p.peek()) {
+		p.skipSpace()
+		key = p.key()
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("invalid key")
+	}
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return "", "", fmt.Errorf("missing = after key %q", key)
+	}
+	p.next()
+	p.skipSpace()
+
+	switch {
+	case p.eof():
+		return key, "", nil
+	case p.peek() == '\'':
+		value, err = p.singleQuoted()
+	case p.peek() == '"':
+		value, err = p.doubleQuoted()
+	default:
+		return key, p.unquoted(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	p.skipSpace()
+	switch {
+	case p.eof():
+	case p.peek() == '\n':
+		p.next()
+	case p.peek() == '#':
+		p.skipLine()
+	default:
+		return "", "", fmt.Errorf("unexpected characters after quoted value")
+	}
+	return key, value, nil
+}
+
+func (p *parser) key() string {
+	start := p.pos
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) unquoted() string {
+	start := p.pos
+	end := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		c := p.next()
+		if c == '#' && (p.pos-1 == start || isSpace(p.src[p.pos-2])) {
+			p.skipLine()
+			return strings.TrimSpace(p.src[start:end])
+		}
+		end = p.pos
+	}
+	if !p.eof() {
+		p.next()
+	}
+	return strings.TrimSpace(p.src[start:end])
+}
+
+func (p *parser) singleQuoted() (string, error) {
+	p.next()
+	start := p.pos
+	for !p.eof() {
+		if p.next() == '\'' {
+			return p.src[start : p.pos-1], nil
+		}
+	}
+	return "", fmt.Errorf("unterminated single quoted value")
+}
+
+func (p *parser) doubleQuoted() (string, error) {
+	p.next()
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				break
+			}
+			switch e := p.next(); e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '"', '\\', '$', '\'':
+				sb.WriteByte(e)
+			case '\n':
+				// Line continuation.
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated double quoted value")
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && isSpace(p.peek()) {
+		p.next()
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		('a' <= c && c <= 'z') ||
+		('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9')
+}
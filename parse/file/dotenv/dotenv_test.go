@@ -0,0 +1,131 @@
+package dotenv
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func TestSyntaxUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		prefix string
+		input  string
+		exp    map[string]interface{}
+		err    bool
+	}{
+		{
+			name: "basic",
+			input: "" +
+				"# comment\n" +
+				"\n" +
+				"FOO=bar\n" +
+				"export BAZ = qux # trailing comment\n" +
+				"EMPTY=\n" +
+				"HASH=a#b\n",
+			exp: map[string]interface{}{
+				"foo":   "bar",
+				"baz":   "qux",
+				"empty": "",
+				"hash":  "a#b",
+			},
+		},
+		{
+			name: "quotes",
+			input: "" +
+				"SINGLE='a \\n ${b}'\n" +
+				"DOUBLE=\"a\\tb \\\"c\\\"\" # comment\n" +
+				"MULTI=\"line 1\nline 2\"\n" +
+				"RAW='line 1\nline 2'\n",
+			exp: map[string]interface{}{
+				"single": "a \\n ${b}",
+				"double": "a\tb \"c\"",
+				"multi":  "line 1\nline 2",
+				"raw":    "line 1\nline 2",
+			},
+		},
+		{
+			name:   "names",
+			prefix: "APP_",
+			input: "" +
+				"APP_DATABASE__MAX_CONNS=8\n" +
+				"APP_DEBUG=true\n" +
+				"OTHER=1\n",
+			exp: map[string]interface{}{
+				"database.max-conns": "8",
+				"debug":              "true",
+			},
+		},
+		{
+			name:  "unterminated",
+			input: "FOO=\"bar\n",
+			err:   true,
+		},
+		{
+			name:  "missing assignment",
+			input: "FOO\n",
+			err:   true,
+		},
+		{
+			name:  "garbage after quote",
+			input: "FOO='bar' baz\n",
+			err:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := Syntax{
+				Prefix: test.prefix,
+			}
+			act, err := s.Unmarshal([]byte(test.input))
+			if test.err {
+				if err == nil {
+					t.Fatalf("want error; got nothing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cmp.Equal(act, test.exp) {
+				t.Errorf("unexpected values:\n%s", cmp.Diff(test.exp, act))
+			}
+		})
+	}
+}
+
+func TestSyntaxParse(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	port := fs.Int("port", 0, "")
+	endpoint := fs.String("database.endpoint", "", "")
+	name := fs.String("name", "default", "")
+	comment := fs.String("comment", "default", "")
+
+	p := file.Parser{
+		Lookup: file.BytesLookup("" +
+			"APP_PORT=4050\n" +
+			"APP_DATABASE__ENDPOINT=\"localhost:5432\"\n" +
+			"APP_NAME=\n" +
+			"export APP_COMMENT=\n",
+		),
+		Syntax: &Syntax{
+			Prefix: "APP_",
+		},
+	}
+	if err := p.Parse(context.Background(), parse.NewFlagSet(fs)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act, exp := *port, 4050; act != exp {
+		t.Errorf("unexpected port: %d; want %d", act, exp)
+	}
+	if act, exp := *endpoint, "localhost:5432"; act != exp {
+		t.Errorf("unexpected endpoint: %q; want %q", act, exp)
+	}
+	if *name != "" || *comment != "" {
+		t.Errorf("want empty values; got %q and %q", *name, *comment)
+	}
+}
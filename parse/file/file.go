@@ -3,11 +3,17 @@ package file
 import (
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/gobwas/flagutil/parse"
 )
@@ -17,6 +23,144 @@ type Syntax interface {
 	Unmarshal([]byte) (map[string]interface{}, error)
 }
 
+// Encoder is an optional interface which Syntax may implement to encode
+// structured values back into the syntax.
+type Encoder interface {
+	Marshal(map[string]interface{}) ([]byte, error)
+}
+
+// PositionSyntax is an optional interface which Syntax may implement to
+// report positions of the keys within the source. It is used by Parser to
+// describe errors in strict mode.
+type PositionSyntax interface {
+	Syntax
+
+	// Positions returns positions of the keys within source p. Keys of
+	// nested mappings are joined by parse.SetSeparator. Positions may omit
+	// keys it can not locate (see limitations of particular syntax, such as
+	// keys of TOML inline tables and arrays of tables); Parser uses position
+	// of the closest parent key for them, or reports no position if there
+	// is no such key. Non-nil error fails the parsing.
+	Positions(p []byte) (map[string]Position, error)
+}
+
+// EmptyValueSyntax is an optional interface which Syntax may implement to
+// make Parser set empty string values (such as `KEY=` line of .env file).
+// By default empty values are rejected (see parse.WithEmptyValues()).
+type EmptyValueSyntax interface {
+	Syntax
+
+	// EmptyValues reports whether empty string values must be set.
+	EmptyValues() bool
+}
+
+// Position describes position within the source.
+type Position struct {
+	// Line is a line number starting at 1.
+	Line int
+
+	// Column is a column number starting at 1. Column is counted in runes.
+	Column int
+}
+
+// IsValid returns true if position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// PositionOf returns position of the given byte offset within source p.
+func PositionOf(p []byte, offset int) Position {
+	if offset > len(p) {
+		offset = len(p)
+	}
+	line := p[:offset]
+	n := bytes.Count(line, []byte{'\n'})
+	if i := bytes.LastIndexByte(line, '\n'); i != -1 {
+		line = line[i+1:]
+	}
+	return Position{
+		Line:   n + 1,
+		Column: utf8.RuneCount(line) + 1,
+	}
+}
+
+// Error describes an error of filling flag value from the source key in
+// strict mode.
+type Error struct {
+	// Path is a path to the source file. It is empty if source is not a
+	// file.
+	Path string
+
+	// Key is a path to the value within the source. Keys of nested mappings
+	// are joined by parse.SetSeparator.
+	Key string
+
+	// Position is a position of the key within the source. It is not valid
+	// if Syntax doesn't implement PositionSyntax or the key position is not
+	// known.
+	Position Position
+
+	// Value is the string representation of the value.
+	Value string
+
+	// Err is an underlying error. It matches parse.ErrUndefined if key is
+	// not defined.
+	Err error
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	if e.Path != "" {
+		sb.WriteString(e.Path)
+		sb.WriteString(":")
+	}
+	if e.Position.IsValid() {
+		sb.WriteString(e.Position.String())
+		sb.WriteString(":")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString(" ")
+	}
+	switch {
+	case errors.Is(e.Err, parse.ErrUndefined):
+		fmt.Fprintf(&sb, "unknown key %q", e.Key)
+	case e.Value != "":
+		err := e.Err
+		if x := errors.Unwrap(err); x != nil {
+			// Strip the "set <name>" prefix.
+			err = x
+		}
+		fmt.Fprintf(&sb, "invalid value %q of key %q: %v", e.Value, e.Key, err)
+	default:
+		fmt.Fprintf(&sb, "key %q: %v", e.Key, e.Err)
+	}
+	return sb.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors holds all errors occurred during parsing in strict mode.
+type Errors []*Error
+
+func (es Errors) Error() string {
+	if len(es) == 1 {
+		return "file: " + es[0].Error()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "file: %d errors:", len(es))
+	for _, e := range es {
+		sb.WriteString("\n  ")
+		sb.WriteString(e.Error())
+	}
+	return sb.String()
+}
+
 // Lookup is an interface to search for syntax source.
 type Lookup interface {
 	Lookup() (io.ReadCloser, error)
@@ -102,6 +246,125 @@ func (p PathLookup) Lookup() (io.ReadCloser, error) {
 	return os.Open(string(p))
 }
 
+// Paths returns paths to the files which may be opened by given lookup.
+// It returns nil if lookup is not known to open files.
+func Paths(l Lookup) []string {
+	switch x := l.(type) {
+	case PathLookup:
+		return []string{string(x)}
+	case *FlagLookup:
+		if f := x.FlagSet.Lookup(x.Name); f != nil {
+			if path := f.Value.String(); path != "" {
+				return []string{path}
+			}
+		}
+	case MultiLookup:
+		var paths []string
+		for _, l := range x {
+			paths = append(paths, Paths(l)...)
+		}
+		return paths
+	case Layers:
+		var paths []string
+		for _, l := range x {
+			paths = append(paths, Paths(l)...)
+		}
+		return paths
+	case GlobLookup:
+		paths, _ := x.paths()
+		return paths
+	case XDGLookup:
+		return x.paths()
+	}
+	return nil
+}
+
+// FlagNames returns names of the flags whose values are used by given lookup
+// as paths to the files. Lookups which search for files by themselves (such
+// as XDGLookup or GlobLookup) use no flags.
+func FlagNames(l Lookup) []string {
+	switch x := l.(type) {
+	case *FlagLookup:
+		return []string{x.Name}
+	case MultiLookup:
+		var names []string
+		for _, l := range x {
+			names = append(names, FlagNames(l)...)
+		}
+		return names
+	case Layers:
+		var names []string
+		for _, l := range x {
+			names = append(names, FlagNames(l)...)
+		}
+		return names
+	}
+	return nil
+}
+
+// XDGLookup prepares search for the configuration file according to the XDG
+// Base Directory Specification. That is, it searches for
+// $XDG_CONFIG_HOME/<App>/<Name> and then for <App>/<Name> within each of the
+// $XDG_CONFIG_DIRS directories. The first existing file is used.
+//
+// If $XDG_CONFIG_HOME is empty, $HOME/.config is used. If $XDG_CONFIG_DIRS is
+// empty, /etc/xdg is used. Relative paths within these variables are ignored
+// as the specification requires.
+type XDGLookup struct {
+	App  string
+	Name string
+
+	// LookupEnvFunc is used to get environment variables.
+	// If nil, os.LookupEnv() is used.
+	LookupEnvFunc func(string) (string, bool)
+}
+
+// Lookup implements Lookup interface.
+func (x XDGLookup) Lookup() (io.ReadCloser, error) {
+	var ls MultiLookup
+	for _, path := range x.paths() {
+		ls = append(ls, PathLookup(path))
+	}
+	return ls.Lookup()
+}
+
+func (x XDGLookup) paths() (paths []string) {
+	dirs := []string{x.getenv("XDG_CONFIG_HOME")}
+	if dirs[0] == "" {
+		if home := x.home(); home != "" {
+			dirs[0] = filepath.Join(home, ".config")
+		}
+	}
+	if s := x.getenv("XDG_CONFIG_DIRS"); s != "" {
+		dirs = append(dirs, filepath.SplitList(s)...)
+	} else {
+		dirs = append(dirs, "/etc/xdg")
+	}
+	for _, dir := range dirs {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, x.App, x.Name))
+	}
+	return paths
+}
+
+func (x XDGLookup) home() string {
+	if x.LookupEnvFunc != nil {
+		return x.getenv("HOME")
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+func (x XDGLookup) getenv(name string) string {
+	if f := x.LookupEnvFunc; f != nil {
+		v, _ := f(name)
+		return v
+	}
+	return os.Getenv(name)
+}
+
 // BytesLookup succeeds source lookup with itself.
 type BytesLookup []byte
 
@@ -110,6 +373,136 @@ func (b BytesLookup) Lookup() (io.ReadCloser, error) {
 	return ioutil.NopCloser(bytes.NewReader(b)), nil
 }
 
+// LayeredLookup is an optional interface for Lookup implementations which
+// search for multiple sources. If Parser.Lookup implements LayeredLookup, all
+// sources it returns are parsed and deep-merged before filling flag values.
+type LayeredLookup interface {
+	Lookup
+
+	// LookupAll returns all found sources in order of increasing precedence.
+	// It returns ErrNoFile if no sources were found.
+	LookupAll() ([]io.ReadCloser, error)
+}
+
+// Layers holds Lookup implementations whose sources must be merged.
+// Sources found by latter lookups take precedence over the sources found by
+// former ones. That is, values of latter sources override values of former
+// sources; nested maps are merged key by key.
+//
+// For example, the following layers make user's configuration override the
+// system-wide one, and files within conf.d directory override both in lexical
+// order:
+//
+//	file.Layers{
+//		file.PathLookup("/etc/app/config.yaml"),
+//		file.PathLookup(filepath.Join(home, ".config/app/config.yaml")),
+//		file.GlobLookup("/etc/app/conf.d/*.yaml"),
+//	}
+type Layers []Lookup
+
+// LookupAll implements LayeredLookup interface.
+func (ls Layers) LookupAll() (rcs []io.ReadCloser, err error) {
+	defer func() {
+		if err != nil {
+			for _, rc := range rcs {
+				rc.Close()
+			}
+			rcs = nil
+		}
+	}()
+	for _, l := range ls {
+		var xs []io.ReadCloser
+		if ll, ok := l.(LayeredLookup); ok {
+			xs, err = ll.LookupAll()
+		} else {
+			var rc io.ReadCloser
+			rc, err = l.Lookup()
+			xs = []io.ReadCloser{rc}
+		}
+		if err == ErrNoFile {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return rcs, err
+		}
+		rcs = append(rcs, xs...)
+	}
+	if len(rcs) == 0 {
+		return nil, ErrNoFile
+	}
+	return rcs, nil
+}
+
+// Lookup implements Lookup interface.
+// It returns the found source with the highest precedence.
+func (ls Layers) Lookup() (io.ReadCloser, error) {
+	return lookupLast(ls)
+}
+
+// GlobLookup prepares search for all files matching the pattern (see
+// filepath.Match()). Matched files are opened in lexical order, which makes
+// it suitable to load conf.d directories. Directories are skipped.
+type GlobLookup string
+
+// LookupAll implements LayeredLookup interface.
+func (g GlobLookup) LookupAll() (rcs []io.ReadCloser, err error) {
+	paths, err := g.paths()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			for _, rc := range rcs {
+				rc.Close()
+			}
+			return nil, err
+		}
+		rcs = append(rcs, f)
+	}
+	if len(rcs) == 0 {
+		return nil, ErrNoFile
+	}
+	return rcs, nil
+}
+
+// Lookup implements Lookup interface.
+// It returns the last matched file.
+func (g GlobLookup) Lookup() (io.ReadCloser, error) {
+	return lookupLast(g)
+}
+
+func (g GlobLookup) paths() ([]string, error) {
+	matches, err := filepath.Glob(string(g))
+	if err != nil {
+		return nil, fmt.Errorf("file: glob %q: %v", g, err)
+	}
+	sort.Strings(matches)
+	paths := matches[:0]
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func lookupLast(l LayeredLookup) (io.ReadCloser, error) {
+	rcs, err := l.LookupAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, rc := range rcs[:len(rcs)-1] {
+		rc.Close()
+	}
+	return rcs[len(rcs)-1], nil
+}
+
 // Parser contains options of parsing source and filling flag values.
 type Parser struct {
 	// Lookup contains logic of how configuration source must be opened.
@@ -121,11 +514,31 @@ type Parser struct {
 
 	// Syntax contains logic of parsing source.
 	Syntax Syntax
+
+	// Section specifies path to the part of the source which must be used to
+	// fill flag values. Path elements are separated by parse.SetSeparator.
+	// Empty Section means that the whole source is used.
+	Section string
+
+	// Strict makes Parser to not stop on the first undefined key or invalid
+	// value but report all of them as Errors. Errors hold positions of the
+	// keys if Syntax implements PositionSyntax. Errors are reported even if
+	// flag set collects errors (see parse.CollectErrors()).
+	Strict bool
 }
 
 // Parse implements flagutil.Parser interface.
 func (p *Parser) Parse(_ context.Context, fs parse.FlagSet) error {
-	bts, err := p.readSource()
+	if p.Strict && parse.CollectingErrors(fs) {
+		// Report errors as Errors holding positions of the keys instead of
+		// letting fs to collect them.
+		parse.CollectErrors(fs, false)
+		defer parse.CollectErrors(fs, true)
+	}
+	if _, ok := p.Lookup.(LayeredLookup); ok {
+		return p.parseLayers(fs)
+	}
+	bts, path, err := p.readSource()
 	if err == ErrNoFile {
 		if p.Required {
 			err = fmt.Errorf("file: source not found")
@@ -139,25 +552,290 @@ func (p *Parser) Parse(_ context.Context, fs parse.FlagSet) error {
 	if len(bts) == 0 {
 		return nil
 	}
-	x, err := p.Syntax.Unmarshal(bts)
+	var x interface{}
+	x, err = p.Syntax.Unmarshal(bts)
 	if err != nil {
 		return fmt.Errorf("file: syntax error: %v", err)
 	}
-	return parse.Setup(x, parse.VisitorFunc{
+	var prefix string
+	if p.Section != "" {
+		path := strings.Split(p.Section, parse.SetSeparator)
+		s, has := section(x, path)
+		if !has {
+			return nil
+		}
+		x = s
+		prefix = strings.Join(path, parse.SetSeparator)
+	}
+	if path != "" {
+		parse.SetLocation(fs, path)
+	}
+	v := parse.VisitorFunc{
 		SetFunc: func(name, value string) error {
 			return fs.Set(name, value)
 		},
 		HasFunc: func(name string) bool {
 			return fs.Lookup(name) != nil
 		},
+	}
+	if !p.Strict {
+		return parse.Setup(x, v, p.setupOptions()...)
+	}
+	src := source{
+		path: path,
+		bts:  bts,
+	}
+	return p.strictErrors(parse.SetupAll(x, v, p.setupOptions()...), prefix, func(string) source {
+		return src
 	})
 }
 
-func (p *Parser) readSource() ([]byte, error) {
+// Key returns path to the key within the source whose value is set to the
+// flag with given name. That is, name prefixed by Section. Path elements are
+// separated by parse.SetSeparator.
+func (p *Parser) Key(name string) string {
+	return parse.Join(p.Section, name)
+}
+
+func (p *Parser) setupOptions() []parse.SetupOption {
+	if s, ok := p.Syntax.(EmptyValueSyntax); ok && s.EmptyValues() {
+		return []parse.SetupOption{
+			parse.WithEmptyValues(),
+		}
+	}
+	return nil
+}
+
+// Kind implements flagutil.KindParser interface.
+func (p *Parser) Kind() string {
+	return "file"
+}
+
+func (p *Parser) parseLayers(fs parse.FlagSet) error {
+	srcs, err := p.readSources()
+	if err != nil {
+		return err
+	}
+	if len(srcs) == 0 {
+		if p.Required {
+			return fmt.Errorf("file: source not found")
+		}
+		return nil
+	}
+	var (
+		root    = make(map[string]interface{})
+		origins = make(map[string]string)
+	)
+	for _, src := range srcs {
+		if len(src.bts) == 0 {
+			continue
+		}
+		x, err := p.Syntax.Unmarshal(src.bts)
+		if err != nil {
+			return fmt.Errorf("file: %s: syntax error: %v", src.path, err)
+		}
+		merge(root, reflect.ValueOf(x), "", src.path, origins)
+	}
+	var (
+		x      interface{} = root
+		prefix string
+	)
+	if p.Section != "" {
+		path := strings.Split(p.Section, parse.SetSeparator)
+		s, has := section(x, path)
+		if !has {
+			return nil
+		}
+		x = s
+		prefix = strings.Join(path, parse.SetSeparator)
+	}
+	v := parse.VisitorFunc{
+		SetFunc: func(name, value string) error {
+			origin := origins[parse.Join(prefix, name)]
+			parse.SetLocation(fs, origin)
+			err := fs.Set(name, value)
+			if err != nil && !p.Strict {
+				return fmt.Errorf("file: %s: %w", origin, err)
+			}
+			return err
+		},
+		HasFunc: func(name string) bool {
+			return fs.Lookup(name) != nil
+		},
+	}
+	if !p.Strict {
+		return parse.Setup(x, v, p.setupOptions()...)
+	}
+	return p.strictErrors(parse.SetupAll(x, v, p.setupOptions()...), prefix, func(key string) source {
+		origin := origins[key]
+		for i := len(srcs) - 1; i >= 0; i-- {
+			if srcs[i].path == origin {
+				return srcs[i]
+			}
+		}
+		return source{path: origin}
+	})
+}
+
+// strictErrors converts errors returned by parse.SetupAll() into Errors.
+// Keys of the errors are prefixed by prefix; source of the key is returned by
+// src.
+func (p *Parser) strictErrors(err error, prefix string, src func(key string) source) error {
+	var es parse.SetupErrors
+	if !errors.As(err, &es) {
+		return err
+	}
+	var (
+		ret       = make(Errors, len(es))
+		positions = make(map[string]map[string]Position)
+	)
+	for i, e := range es {
+		key := parse.Join(prefix, e.Key)
+		s := src(key)
+		pos, has := positions[s.path]
+		if !has {
+			var err error
+			if pos, err = p.positions(s.bts); err != nil {
+				return fmt.Errorf("file: %s: key positions: %w", s.path, err)
+			}
+			positions[s.path] = pos
+		}
+		ret[i] = &Error{
+			Path:     s.path,
+			Key:      key,
+			Position: lookupPosition(pos, key),
+			Value:    e.Value,
+			Err:      e.Err,
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		a, b := ret[i], ret[j]
+		switch {
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		case a.Position.Line != b.Position.Line:
+			return a.Position.Line < b.Position.Line
+		case a.Position.Column != b.Position.Column:
+			return a.Position.Column < b.Position.Column
+		}
+		return a.Key < b.Key
+	})
+	return ret
+}
+
+// positions returns positions of the keys within source p. It returns nil if
+// positions are not available.
+func (p *Parser) positions(bts []byte) (map[string]Position, error) {
+	ps, ok := p.Syntax.(PositionSyntax)
+	if !ok || len(bts) == 0 {
+		return nil, nil
+	}
+	return ps.Positions(bts)
+}
+
+// lookupPosition returns position of the key. If key position is not known it
+// returns position of the closest known parent key.
+func lookupPosition(m map[string]Position, key string) Position {
+	for {
+		if pos, has := m[key]; has {
+			return pos
+		}
+		i := strings.LastIndex(key, parse.SetSeparator)
+		if i == -1 {
+			return Position{}
+		}
+		key = key[:i]
+	}
+}
+
+// merge deep-merges map src into dst. Values of src take precedence over the
+// values of dst: nested maps are merged recursively while other values are
+// replaced. Path of every merged value (including maps) is associated with
+// the given origin within origins.
+func merge(dst map[string]interface{}, src reflect.Value, key, origin string, origins map[string]string) {
+	for iter := src.MapRange(); iter.Next(); {
+		var (
+			k    = fmt.Sprint(iter.Key().Interface())
+			v    = iter.Value().Interface()
+			path = parse.Join(key, k)
+		)
+		origins[path] = origin
+		if x := reflect.ValueOf(v); x.Kind() == reflect.Map {
+			sub, ok := dst[k].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				dst[k] = sub
+			}
+			merge(sub, x, path, origin, origins)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// section returns the part of x at given path.
+func section(x interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		v := reflect.ValueOf(x)
+		if v.Kind() != reflect.Map {
+			return nil, false
+		}
+		var found bool
+		for iter := v.MapRange(); iter.Next(); {
+			if fmt.Sprint(iter.Key().Interface()) == key {
+				x = iter.Value().Interface()
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return x, reflect.ValueOf(x).Kind() == reflect.Map
+}
+
+func (p *Parser) readSource() (bts []byte, path string, err error) {
 	src, err := p.Lookup.Lookup()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer src.Close()
-	return ioutil.ReadAll(src)
+	if f, ok := src.(interface{ Name() string }); ok {
+		path = f.Name()
+	}
+	bts, err = ioutil.ReadAll(src)
+	return bts, path, err
+}
+
+type source struct {
+	path string
+	bts  []byte
+}
+
+func (p *Parser) readSources() (srcs []source, err error) {
+	rcs, err := p.Lookup.(LayeredLookup).LookupAll()
+	if err == ErrNoFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, rc := range rcs {
+			rc.Close()
+		}
+	}()
+	for _, rc := range rcs {
+		var src source
+		if f, ok := rc.(interface{ Name() string }); ok {
+			src.path = f.Name()
+		}
+		src.bts, err = ioutil.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, src)
+	}
+	return srcs, nil
 }
@@ -2,11 +2,21 @@ package file
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
+	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/testutil"
 )
 
 var (
@@ -14,6 +24,10 @@ var (
 	_ Lookup = &FlagLookup{}
 	_ Lookup = PathLookup("")
 	_ Lookup = BytesLookup{}
+	_ Lookup = XDGLookup{}
+
+	_ LayeredLookup = Layers{}
+	_ LayeredLookup = GlobLookup("")
 )
 
 func TestPathLookupDir(t *testing.T) {
@@ -106,3 +120,438 @@ func tempFile() (file *os.File, content []byte, err error) {
 
 	return file, content, nil
 }
+
+type stubSyntax map[string]interface{}
+
+func (s stubSyntax) Unmarshal([]byte) (map[string]interface{}, error) {
+	return s, nil
+}
+
+func TestParserSection(t *testing.T) {
+	var fs testutil.StubFlagSet
+	fs.AddFlag("dry-run", "")
+	p := Parser{
+		Lookup: BytesLookup("stub"),
+		Syntax: stubSyntax{
+			"verbose": true,
+			"db": map[interface{}]interface{}{
+				"migrate": map[string]interface{}{
+					"dry-run": true,
+				},
+			},
+		},
+		Section: "db.migrate",
+	}
+	if err := p.Parse(context.Background(), &fs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp, act := [][2]string{{"dry-run", "true"}}, fs.Pairs(); !cmp.Equal(act, exp) {
+		t.Errorf("unexpected set pairs:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+// emptySyntax is a stubSyntax which allows empty values.
+type emptySyntax struct {
+	stubSyntax
+}
+
+func (emptySyntax) EmptyValues() bool {
+	return true
+}
+
+func TestParserEmptyValues(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		syntax Syntax
+		err    bool
+	}{
+		{
+			name:   "rejected",
+			syntax: stubSyntax{"name": ""},
+			err:    true,
+		},
+		{
+			name:   "allowed",
+			syntax: emptySyntax{stubSyntax{"name": ""}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var fs testutil.StubFlagSet
+			fs.AddFlag("name", "default")
+			p := Parser{
+				Lookup: BytesLookup("stub"),
+				Syntax: test.syntax,
+			}
+			err := p.Parse(context.Background(), &fs)
+			if test.err {
+				if err == nil {
+					t.Fatalf("want error; got nothing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exp, act := [][2]string{{"name", ""}}, fs.Pairs(); !cmp.Equal(act, exp) {
+				t.Errorf("unexpected set pairs:\n%s", cmp.Diff(exp, act))
+			}
+		})
+	}
+}
+
+func TestParserStrictSeparator(t *testing.T) {
+	defer func(sep string) {
+		parse.SetSeparator = sep
+	}(parse.SetSeparator)
+	parse.SetSeparator = "/"
+
+	var fs testutil.StubFlagSet
+	p := Parser{
+		Lookup: BytesLookup("stub"),
+		Syntax: stubSyntax{
+			"db": map[string]interface{}{
+				"migrate": map[string]interface{}{
+					"bad": 1,
+				},
+			},
+		},
+		Section: "db/migrate",
+		Strict:  true,
+	}
+	err := p.Parse(context.Background(), &fs)
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want Errors; got %v", err)
+	}
+	if act, exp := errs[0].Key, "db/migrate/bad"; act != exp {
+		t.Errorf("unexpected key: %q; want %q", act, exp)
+	}
+	if act, exp := p.Key("bad"), "db/migrate/bad"; act != exp {
+		t.Errorf("unexpected Key(): %q; want %q", act, exp)
+	}
+}
+
+// lineSyntax parses "a.b=value" lines into nested maps.
+type lineSyntax struct{}
+
+func (lineSyntax) Unmarshal(p []byte) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+		i := strings.IndexByte(line, '=')
+		path := strings.Split(line[:i], ".")
+		m := root
+		for _, key := range path[:len(path)-1] {
+			sub, ok := m[key].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+				m[key] = sub
+			}
+			m = sub
+		}
+		m[path[len(path)-1]] = line[i+1:]
+	}
+	return root, nil
+}
+
+func TestParserLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"system.conf":           "port=1\ndb.host=a\ndb.user=root",
+		"user.conf":             "port=2",
+		"conf.d/20-host.conf":   "db.host=c",
+		"conf.d/10-host.conf":   "db.host=b\ndb.pass=secret",
+		"conf.d/30-dir.conf/xx": "port=3",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	lookup := Layers{
+		PathLookup(path("system.conf")),
+		PathLookup(path("missing.conf")),
+		PathLookup(path("user.conf")),
+		GlobLookup(path("conf.d/*.conf")),
+	}
+	if exp, act := []string{
+		path("system.conf"),
+		path("missing.conf"),
+		path("user.conf"),
+		path("conf.d/10-host.conf"),
+		path("conf.d/20-host.conf"),
+	}, Paths(lookup); !cmp.Equal(act, exp) {
+		t.Errorf("unexpected paths:\n%s", cmp.Diff(exp, act))
+	}
+
+	flags := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	var (
+		port = flags.Int("port", 0, "")
+		host = flags.String("db.host", "", "")
+		user = flags.String("db.user", "", "")
+		pass = flags.String("db.pass", "", "")
+	)
+	fs := parse.NewFlagSet(flags)
+	p := Parser{
+		Lookup: lookup,
+		Syntax: lineSyntax{},
+	}
+	parse.SetSource(fs, &p)
+	if err := p.Parse(context.Background(), fs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	act := []interface{}{*port, *host, *user, *pass}
+	exp := []interface{}{2, "c", "root", "secret"}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected values:\n%s", cmp.Diff(exp, act))
+	}
+	for name, exp := range map[string]string{
+		"port":    path("user.conf"),
+		"db.host": path("conf.d/20-host.conf"),
+		"db.user": path("system.conf"),
+		"db.pass": path("conf.d/10-host.conf"),
+	} {
+		src, _ := parse.LookupSource(fs, name)
+		if act := src.Location; act != exp {
+			t.Errorf("unexpected location of %q: %q; want %q", name, act, exp)
+		}
+	}
+
+	bad := path("conf.d/40-bad.conf")
+	if err := ioutil.WriteFile(bad, []byte("port=foo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	flags = flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	flags.Int("port", 0, "")
+	flags.String("db.host", "", "")
+	flags.String("db.user", "", "")
+	flags.String("db.pass", "", "")
+	err = p.Parse(context.Background(), parse.NewFlagSet(flags))
+	if err == nil || !strings.Contains(err.Error(), bad) {
+		t.Errorf("want error mentioning %s; got %v", bad, err)
+	}
+	if err := os.Remove(bad); err != nil {
+		t.Fatal(err)
+	}
+
+	flags = flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	flags.Int("port", 0, "")
+	err = p.Parse(context.Background(), parse.NewFlagSet(flags))
+	if !errors.Is(err, parse.ErrUndefined) {
+		t.Errorf("want undefined flag error; got %v", err)
+	}
+}
+
+func TestXDGLookup(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		env  map[string]string
+		exp  []string
+	}{
+		{
+			name: "defaults",
+			env: map[string]string{
+				"HOME": "/home/user",
+			},
+			exp: []string{
+				"/home/user/.config/app/config.yaml",
+				"/etc/xdg/app/config.yaml",
+			},
+		},
+		{
+			name: "custom",
+			env: map[string]string{
+				"HOME":            "/home/user",
+				"XDG_CONFIG_HOME": "/home/user/conf",
+				"XDG_CONFIG_DIRS": "/etc/a:relative:/etc/b",
+			},
+			exp: []string{
+				"/home/user/conf/app/config.yaml",
+				"/etc/a/app/config.yaml",
+				"/etc/b/app/config.yaml",
+			},
+		},
+		{
+			name: "relative home",
+			env: map[string]string{
+				"XDG_CONFIG_HOME": "conf",
+			},
+			exp: []string{
+				"/etc/xdg/app/config.yaml",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			lookup := XDGLookup{
+				App:  "app",
+				Name: "config.yaml",
+				LookupEnvFunc: func(name string) (string, bool) {
+					v, has := test.env[name]
+					return v, has
+				},
+			}
+			if act := Paths(lookup); !cmp.Equal(act, test.exp) {
+				t.Errorf("unexpected paths:\n%s", cmp.Diff(test.exp, act))
+			}
+		})
+	}
+}
+
+func TestXDGLookupPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		home = filepath.Join(dir, "home")
+		sys  = filepath.Join(dir, "sys")
+	)
+	env := map[string]string{
+		"XDG_CONFIG_HOME": home,
+		"XDG_CONFIG_DIRS": sys,
+	}
+	lookup := XDGLookup{
+		App:  "app",
+		Name: "config",
+		LookupEnvFunc: func(name string) (string, bool) {
+			v, has := env[name]
+			return v, has
+		},
+	}
+	if _, err := lookup.Lookup(); err != ErrNoFile {
+		t.Fatalf("want ErrNoFile; got %v", err)
+	}
+	read := func() string {
+		rc, err := lookup.Lookup()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer rc.Close()
+		bts, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bts)
+	}
+	for _, d := range []string{home, sys} {
+		if err := os.MkdirAll(filepath.Join(d, "app"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(sys, "app", "config"), []byte("sys"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if act, exp := read(), "sys"; act != exp {
+		t.Errorf("unexpected source: %q; want %q", act, exp)
+	}
+	if err := ioutil.WriteFile(filepath.Join(home, "app", "config"), []byte("home"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if act, exp := read(), "home"; act != exp {
+		t.Errorf("unexpected source: %q; want %q", act, exp)
+	}
+}
+
+// positionSyntax is a lineSyntax reporting positions of the keys.
+type positionSyntax struct {
+	lineSyntax
+}
+
+func (positionSyntax) Positions(p []byte) (map[string]Position, error) {
+	m := make(map[string]Position)
+	for i, line := range strings.Split(string(p), "\n") {
+		if j := strings.IndexByte(line, '='); j != -1 {
+			m[line[:j]] = Position{Line: i + 1, Column: 1}
+		}
+	}
+	return m, nil
+}
+
+type badValue struct{}
+
+func (badValue) String() string   { return "" }
+func (badValue) Set(string) error { return fmt.Errorf("parse error") }
+
+type positionsError struct {
+	lineSyntax
+}
+
+func (positionsError) Positions([]byte) (map[string]Position, error) {
+	return nil, fmt.Errorf("scanner error")
+}
+
+func TestParserStrictPositionsError(t *testing.T) {
+	flags := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	p := Parser{
+		Lookup: BytesLookup("verbose=true"),
+		Syntax: positionsError{},
+		Strict: true,
+	}
+	err := p.Parse(context.Background(), parse.NewFlagSet(flags))
+	if err == nil || !strings.Contains(err.Error(), "scanner error") {
+		t.Fatalf("want positions error; got %v", err)
+	}
+}
+
+func TestParserStrict(t *testing.T) {
+	flags := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	port := flags.Int("port", 0, "")
+	flags.Var(badValue{}, "db.conns", "")
+	host := flags.String("db.host", "", "")
+
+	for _, test := range []struct {
+		name   string
+		syntax Syntax
+		exp    string
+	}{
+		{
+			name:   "positions",
+			syntax: positionSyntax{},
+			exp: "" +
+				"file: 3 errors:\n" +
+				"  1:1: unknown key \"db.hots\"\n" +
+				"  3:1: invalid value \"x\" of key \"db.conns\": parse error\n" +
+				"  4:1: unknown key \"verbose\"",
+		},
+		{
+			name:   "no positions",
+			syntax: lineSyntax{},
+			exp: "" +
+				"file: 3 errors:\n" +
+				"  invalid value \"x\" of key \"db.conns\": parse error\n" +
+				"  unknown key \"db.hots\"\n" +
+				"  unknown key \"verbose\"",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := Parser{
+				Lookup: BytesLookup("db.hots=a\ndb.host=b\ndb.conns=x\nverbose=true\nport=1"),
+				Syntax: test.syntax,
+				Strict: true,
+			}
+			err := p.Parse(context.Background(), parse.NewFlagSet(flags))
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("want Errors; got %v", err)
+			}
+			if act := errs.Error(); act != test.exp {
+				t.Errorf("unexpected error:\n%s", cmp.Diff(test.exp, act))
+			}
+			if *port != 1 || *host != "b" {
+				t.Errorf("valid values must be set")
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Package ini implements INI file syntax.
+//
+// Sections become nested maps; dotted section names such as
+// [database.replica] are nested further. Repeated keys within the same
+// section become lists. Lines starting with ; or # are comments.
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type Syntax struct {
+}
+
+// EmptyValues implements file.EmptyValueSyntax interface.
+// Keys without value (such as `key=`) set flags to empty strings.
+func (s *Syntax) EmptyValues() bool {
+	return true
+}
+
+func (s *Syntax) Unmarshal(p []byte) (map[string]interface{}, error) {
+	var (
+		root    = make(map[string]interface{})
+		section = root
+		line    int
+	)
+	sc := bufio.NewScanner(bytes.NewReader(p))
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || text[0] == ';' || text[0] == '#' {
+			continue
+		}
+		if text[0] == '[' {
+			end := strings.IndexByte(text, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("ini: line %d: unterminated section name", line)
+			}
+			if rest := strings.TrimSpace(text[end+1:]); rest != "" && !isComment(rest) {
+				return nil, fmt.Errorf("ini: line %d: unexpected characters after section name", line)
+			}
+			var err error
+			section, err = lookupSection(root, text[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("ini: line %d: %v", line, err)
+			}
+			continue
+		}
+		i := strings.IndexAny(text, "=:")
+		if i == -1 {
+			return nil, fmt.Errorf("ini: line %d: missing = after key", line)
+		}
+		key := strings.TrimSpace(text[:i])
+		if key == "" {
+			return nil, fmt.Errorf("ini: line %d: empty key", line)
+		}
+		value, err := parseValue(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("ini: line %d: %v", line, err)
+		}
+		switch prev := section[key].(type) {
+		case nil:
+			section[key] = value
+		case string:
+			section[key] = []interface{}{prev, value}
+		case []interface{}:
+			section[key] = append(prev, value)
+		default:
+			return nil, fmt.Errorf("ini: line %d: key %q conflicts with section name", line, key)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func lookupSection(root map[string]interface{}, name string) (map[string]interface{}, error) {
+	m := root
+	for _, part := range strings.Split(name, ".") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("malformed section name %q", name)
+		}
+		switch x := m[part].(type) {
+		case nil:
+			sub := make(map[string]interface{})
+			m[part] = sub
+			m = sub
+		case map[string]interface{}:
+			m = x
+		default:
+			return nil, fmt.Errorf("section %q conflicts with key %q", name, part)
+		}
+	}
+	return m, nil
+}
+
+// parseValue unquotes value if it is quoted or strips the inline comment
+// otherwise. Inline comment must be preceded by a whitespace.
+func parseValue(s string) (string, error) {
+	if s == "" {
+		return s, nil
+	}
+	if q := s[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(s[1:], q)
+		if end == -1 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		end++
+		if rest := strings.TrimSpace(s[end+1:]); rest != "" && !isComment(rest) {
+			return "", fmt.Errorf("unexpected characters after quoted value")
+		}
+		return s[1:end], nil
+	}
+	for i := 1; i < len(s); i++ {
+		if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimSpace(s[:i]), nil
+		}
+	}
+	return s, nil
+}
+
+func isComment(s string) bool {
+	return s[0] == ';' || s[0] == '#'
+}
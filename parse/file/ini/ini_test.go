@@ -0,0 +1,160 @@
+package ini
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+	"github.com/gobwas/flagutil/parse/testutil"
+)
+
+func TestINI(t *testing.T) {
+	testutil.TestParser(t, func(values testutil.Values, fs parse.FlagSet) error {
+		p := file.Parser{
+			Lookup: file.BytesLookup(marshal(values)),
+			Syntax: new(Syntax),
+		}
+		return p.Parse(context.Background(), fs)
+	})
+}
+
+func TestSyntaxUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		input string
+		exp   map[string]interface{}
+		err   bool
+	}{
+		{
+			name: "sections",
+			input: "" +
+				"; comment\n" +
+				"# comment\n" +
+				"debug = true\n" +
+				"\n" +
+				"[database]\n" +
+				"endpoint = localhost:5432 ; inline comment\n" +
+				"user: \"root ; not a comment\"\n" +
+				"\n" +
+				"[database.replica] # comment\n" +
+				"endpoint = 'replica:5432'\n" +
+				"[ database ]\n" +
+				"timeout=1s\n",
+			exp: map[string]interface{}{
+				"debug": "true",
+				"database": map[string]interface{}{
+					"endpoint": "localhost:5432",
+					"user":     "root ; not a comment",
+					"timeout":  "1s",
+					"replica": map[string]interface{}{
+						"endpoint": "replica:5432",
+					},
+				},
+			},
+		},
+		{
+			name: "lists",
+			input: "" +
+				"[server]\n" +
+				"listen = :80\n" +
+				"listen = :443\n" +
+				"listen = :8080\n",
+			exp: map[string]interface{}{
+				"server": map[string]interface{}{
+					"listen": []interface{}{":80", ":443", ":8080"},
+				},
+			},
+		},
+		{
+			name:  "unterminated section",
+			input: "[database\n",
+			err:   true,
+		},
+		{
+			name:  "missing assignment",
+			input: "debug\n",
+			err:   true,
+		},
+		{
+			name: "key conflicts with section",
+			input: "" +
+				"[a.b]\n" +
+				"[a]\n" +
+				"b = 1\n",
+			err: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			act, err := new(Syntax).Unmarshal([]byte(test.input))
+			if test.err {
+				if err == nil {
+					t.Fatalf("want error; got nothing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cmp.Equal(act, test.exp) {
+				t.Errorf("unexpected values:\n%s", cmp.Diff(test.exp, act))
+			}
+		})
+	}
+}
+
+func marshal(values testutil.Values) []byte {
+	var buf bytes.Buffer
+	writeSection(&buf, "", values)
+	return buf.Bytes()
+}
+
+func writeSection(buf *bytes.Buffer, name string, values testutil.Values) {
+	if name != "" {
+		fmt.Fprintf(buf, "[%s]\n", name)
+	}
+	var sections []string
+	for key, value := range values {
+		switch v := value.(type) {
+		case testutil.Values:
+			sections = append(sections, key)
+		case []string:
+			for _, x := range v {
+				fmt.Fprintf(buf, "%s = %s\n", key, x)
+			}
+		default:
+			fmt.Fprintf(buf, "%s = %v\n", key, v)
+		}
+	}
+	sort.Strings(sections)
+	for _, key := range sections {
+		writeSection(buf, parse.Join(name, key), values[key].(testutil.Values))
+	}
+}
+
+func TestSyntaxParse(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	name := fs.String("name", "default", "")
+	endpoint := fs.String("database.endpoint", "default", "")
+
+	p := file.Parser{
+		Lookup: file.BytesLookup("" +
+			"name =\n" +
+			"[database]\n" +
+			"endpoint = \"\"\n",
+		),
+		Syntax: new(Syntax),
+	}
+	if err := p.Parse(context.Background(), parse.NewFlagSet(fs)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *name != "" || *endpoint != "" {
+		t.Errorf("want empty values; got %q and %q", *name, *endpoint)
+	}
+}
@@ -1,6 +1,12 @@
 package json
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
 
 type Syntax struct {
 }
@@ -9,3 +15,80 @@ func (s *Syntax) Unmarshal(p []byte) (m map[string]interface{}, err error) {
 	err = json.Unmarshal(p, &m)
 	return
 }
+
+func (s *Syntax) Marshal(m map[string]interface{}) ([]byte, error) {
+	p, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(p, '\n'), nil
+}
+
+// Positions implements file.PositionSyntax interface.
+func (s *Syntax) Positions(p []byte) (map[string]file.Position, error) {
+	d := decoder{
+		Decoder: json.NewDecoder(bytes.NewReader(p)),
+		src:     p,
+		pos:     make(map[string]file.Position),
+	}
+	if err := d.value(""); err != nil {
+		return nil, err
+	}
+	return d.pos, nil
+}
+
+type decoder struct {
+	*json.Decoder
+	src []byte
+	pos map[string]file.Position
+}
+
+func (d *decoder) value(key string) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for d.More() {
+			tok, err := d.Token()
+			if err != nil {
+				return err
+			}
+			k := parse.Join(key, tok.(string))
+			d.pos[k] = file.PositionOf(d.src, d.keyStart())
+			if err := d.value(k); err != nil {
+				return err
+			}
+		}
+		_, err = d.Token()
+
+	case json.Delim('['):
+		for d.More() {
+			if err := d.value(key); err != nil {
+				return err
+			}
+		}
+		_, err = d.Token()
+	}
+	return err
+}
+
+// keyStart returns offset of the opening quote of the object key which has
+// just been read.
+func (d *decoder) keyStart() int {
+	i := int(d.InputOffset()) - 2 // Skip the closing quote.
+	for ; i >= 0; i-- {
+		if d.src[i] != '"' {
+			continue
+		}
+		var n int
+		for j := i - 1; j >= 0 && d.src[j] == '\\'; j-- {
+			n++
+		}
+		if n%2 == 0 {
+			break
+		}
+	}
+	return i
+}
@@ -3,13 +3,22 @@ package json
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/gobwas/flagutil/parse"
 	"github.com/gobwas/flagutil/parse/file"
 	"github.com/gobwas/flagutil/parse/testutil"
 )
 
+var (
+	_ file.Encoder        = new(Syntax)
+	_ file.PositionSyntax = new(Syntax)
+)
+
 func TestJSON(t *testing.T) {
 	testutil.TestParser(t, func(values testutil.Values, fs parse.FlagSet) error {
 		p := file.Parser{
@@ -27,3 +36,64 @@ func marshal(values testutil.Values) []byte {
 	}
 	return bts
 }
+
+func TestSyntaxPositions(t *testing.T) {
+	src := []byte(`{
+  "port": 4050,
+  "database": {
+    "endpoint": "localhost",
+    "tags": [{"a": 1}, "b"],
+    "we\\\"ird": true
+  },
+  "\u0070ool": "x"
+}`)
+	act, err := new(Syntax).Positions(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]file.Position{
+		"port":               {Line: 2, Column: 3},
+		"database":           {Line: 3, Column: 3},
+		"database.endpoint":  {Line: 4, Column: 5},
+		"database.tags":      {Line: 5, Column: 5},
+		"database.tags.a":    {Line: 5, Column: 15},
+		"database.we\\\"ird": {Line: 6, Column: 5},
+		"pool":               {Line: 8, Column: 3},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected positions:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestParserStrict(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.String("database.endpoint", "", "")
+	p := file.Parser{
+		Lookup: file.BytesLookup(`{
+  "port": "x",
+  "database": {
+    "endpoint": "localhost",
+    "user": "root"
+  }
+}`),
+		Syntax: new(Syntax),
+		Strict: true,
+	}
+	err := p.Parse(context.Background(), parse.NewFlagSet(fs))
+	var errs file.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want file.Errors; got %v", err)
+	}
+	act := make(map[string]file.Position)
+	for _, e := range errs {
+		act[e.Key] = e.Position
+	}
+	exp := map[string]file.Position{
+		"port":          {Line: 2, Column: 3},
+		"database.user": {Line: 5, Column: 5},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected errors positions:\n%s", cmp.Diff(exp, act))
+	}
+}
@@ -0,0 +1,158 @@
+// Package properties implements Java .properties file syntax.
+//
+// Keys are separated from values by '=', ':' or whitespace. Lines ending with
+// a backslash are continued on the next line. Lines starting with # or ! are
+// comments. Keys without value (such as "key=" or just "key") set flags to
+// empty strings. Dotted keys are not nested: key "database.endpoint" is
+// mapped to the flag with the same name (with dots replaced by
+// flagutil.SetSeparator).
+package properties
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gobwas/flagutil"
+)
+
+type Syntax struct {
+}
+
+// EmptyValues implements file.EmptyValueSyntax interface.
+// Keys without value set flags to empty strings.
+func (s *Syntax) EmptyValues() bool {
+	return true
+}
+
+func (s *Syntax) Unmarshal(p []byte) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	lines := strings.Split(strings.ReplaceAll(string(p), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		start := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		// Join continued lines. Note that comment lines can not be continued.
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continued(line) {
+			line = line[:len(line)-1]
+		}
+		key, value, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %v", start, err)
+		}
+		if flagutil.SetSeparator != "." {
+			key = strings.ReplaceAll(key, ".", flagutil.SetSeparator)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+// continued reports whether line ends with an odd number of backslashes.
+func continued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func parseLine(line string) (key, value string, err error) {
+	i := 0
+	for ; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || isSpace(c) {
+			break
+		}
+	}
+	if i > len(line) {
+		i = len(line)
+	}
+	key, err = unescape(line[:i])
+	if err != nil {
+		return "", "", err
+	}
+	rest := strings.TrimLeft(line[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err = unescape(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescape(s string) (string, error) {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed unicode escape: %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed unicode escape: %q", s[i-1:i+5])
+			}
+			i += 4
+			if isHighSurrogate(rune(r)) {
+				if lo, ok := lowSurrogate(s[i+1:]); ok {
+					r = uint64(0x10000 + (rune(r)-0xd800)<<10 + (lo - 0xdc00))
+					i += 6
+				}
+			}
+			sb.WriteRune(rune(r))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+func isHighSurrogate(r rune) bool {
+	return 0xd800 <= r && r < 0xdc00
+}
+
+// lowSurrogate parses \uXXXX low surrogate escape at the beginning of s.
+func lowSurrogate(s string) (rune, bool) {
+	if len(s) < 6 || s[0] != '\\' || s[1] != 'u' {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(s[2:6], 16, 16)
+	if err != nil || r < 0xdc00 || r > 0xdfff {
+		return 0, false
+	}
+	return rune(r), true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
@@ -0,0 +1,131 @@
+package properties
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func TestSyntaxUnmarshal(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		input string
+		exp   map[string]interface{}
+		err   bool
+	}{
+		{
+			name: "separators",
+			input: "" +
+				"# comment\n" +
+				"! comment\n" +
+				"\n" +
+				"a=1\n" +
+				"b : 2\n" +
+				"c 3\n" +
+				"  d\t=  4\n" +
+				"e\n" +
+				"f=\n",
+			exp: map[string]interface{}{
+				"a": "1",
+				"b": "2",
+				"c": "3",
+				"d": "4",
+				"e": "",
+				"f": "",
+			},
+		},
+		{
+			name: "continuation",
+			input: "" +
+				"list = a, \\\n" +
+				"       b, \\\n" +
+				"       c\n" +
+				"path = C:\\\\dir\\\\\n" +
+				"next = x\n",
+			exp: map[string]interface{}{
+				"list": "a, b, c",
+				"path": `C:\dir\`,
+				"next": "x",
+			},
+		},
+		{
+			name: "escapes",
+			input: "" +
+				"key\\ with\\=separators = value\n" +
+				"tab = a\\tb\n" +
+				"unicode = \\u041f\\u0440\\u0438\\u0432\\u0435\\u0442\n" +
+				"surrogate = \\ud83d\\ude00\n",
+			exp: map[string]interface{}{
+				"key with=separators": "value",
+				"tab":                 "a\tb",
+				"unicode":             "Привет",
+				"surrogate":           "😀",
+			},
+		},
+		{
+			name: "dotted keys",
+			input: "" +
+				"database.endpoint = localhost\n" +
+				"database.endpoint = override\n",
+			exp: map[string]interface{}{
+				"database.endpoint": "override",
+			},
+		},
+		{
+			name:  "malformed unicode",
+			input: "a = \\u12\n",
+			err:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			act, err := new(Syntax).Unmarshal([]byte(test.input))
+			if test.err {
+				if err == nil {
+					t.Fatalf("want error; got nothing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cmp.Equal(act, test.exp) {
+				t.Errorf("unexpected values:\n%s", cmp.Diff(test.exp, act))
+			}
+		})
+	}
+}
+
+func TestSyntaxParse(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	port := fs.Int("port", 0, "")
+	endpoint := fs.String("database.endpoint", "", "")
+	name := fs.String("name", "default", "")
+	comment := fs.String("comment", "default", "")
+
+	p := file.Parser{
+		Lookup: file.BytesLookup("" +
+			"port: 4050\n" +
+			"database.endpoint = localhost:5432\n" +
+			"name=\n" +
+			"comment\n",
+		),
+		Syntax: new(Syntax),
+	}
+	if err := p.Parse(context.Background(), parse.NewFlagSet(fs)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act, exp := *port, 4050; act != exp {
+		t.Errorf("unexpected port: %d; want %d", act, exp)
+	}
+	if act, exp := *endpoint, "localhost:5432"; act != exp {
+		t.Errorf("unexpected endpoint: %q; want %q", act, exp)
+	}
+	if *name != "" || *comment != "" {
+		t.Errorf("want empty values; got %q and %q", *name, *comment)
+	}
+}
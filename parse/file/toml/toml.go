@@ -1,6 +1,16 @@
 package toml
 
-import "github.com/BurntSushi/toml"
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
 
 type Syntax struct {
 }
@@ -9,3 +19,177 @@ func (s *Syntax) Unmarshal(p []byte) (m map[string]interface{}, err error) {
 	err = toml.Unmarshal(p, &m)
 	return
 }
+
+func (s *Syntax) Marshal(m map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Positions implements file.PositionSyntax interface.
+//
+// Positions scans the source line by line instead of parsing it completely.
+// Keys of inline tables and arrays of tables are not reported, so position
+// of the closest parent key is used for them. Multi-line strings and arrays
+// are skipped; an error is returned if the end of such value can not be
+// found.
+func (s *Syntax) Positions(p []byte) (map[string]file.Position, error) {
+	var (
+		pos   = make(map[string]file.Position)
+		table string
+		// Closing delimiter of the multi-line value being skipped.
+		skip  string
+		depth int
+		start int
+		// Names of the arrays of tables. Keys of their elements are not
+		// reported.
+		arrays = make(map[string]bool)
+		array  bool
+	)
+	set := func(parts []string, line, column int) string {
+		var key string
+		for _, part := range parts {
+			key = parse.Join(key, part)
+			if _, has := pos[key]; !has {
+				pos[key] = file.Position{
+					Line:   line,
+					Column: column,
+				}
+			}
+		}
+		return key
+	}
+	for i, line := range bytes.Split(p, []byte{'\n'}) {
+		text := strings.TrimRight(string(line), " \t\r")
+		if skip != "" {
+			if strings.Contains(text, skip) {
+				skip = ""
+			}
+			continue
+		}
+		if depth > 0 {
+			depth += brackets(text)
+			continue
+		}
+		trim := strings.TrimLeft(text, " \t")
+		if trim == "" || trim[0] == '#' {
+			continue
+		}
+		column := utf8.RuneCountInString(text[:len(text)-len(trim)]) + 1
+		if trim[0] == '[' {
+			name := strings.TrimLeft(trim, "[")
+			if j := strings.IndexByte(name, ']'); j != -1 {
+				name = name[:j]
+			}
+			parts := splitKey(name)
+			array = false
+			for j := range parts {
+				array = array || arrays[strings.Join(parts[:j+1], parse.SetSeparator)]
+			}
+			if array {
+				// Table of the array element.
+				continue
+			}
+			table = set(parts, i+1, column)
+			if strings.HasPrefix(trim, "[[") {
+				arrays[table] = true
+				array = true
+			}
+			continue
+		}
+		eq := index(trim, '=')
+		if eq == -1 {
+			continue
+		}
+		value := strings.TrimSpace(trim[eq+1:])
+		for _, q := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, q) && !strings.Contains(value[3:], q) {
+				skip = q
+			}
+		}
+		if strings.HasPrefix(value, "[") {
+			depth = brackets(value)
+		}
+		if skip != "" || depth > 0 {
+			start = i + 1
+		}
+		if array {
+			continue
+		}
+		parts := splitKey(trim[:eq])
+		if table != "" {
+			parts = append([]string{table}, parts...)
+		}
+		set(parts, i+1, column)
+	}
+	if skip != "" || depth > 0 {
+		return nil, fmt.Errorf("toml: can't find end of the value at line %d", start)
+	}
+	return pos, nil
+}
+
+// splitKey splits dotted key into parts.
+func splitKey(s string) (parts []string) {
+	for {
+		i := index(s, '.')
+		if i == -1 {
+			break
+		}
+		parts = append(parts, unquote(s[:i]))
+		s = s[i+1:]
+	}
+	return append(parts, unquote(s))
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// index returns index of the first occurrence of c in s outside of quotes.
+func index(s string, c byte) int {
+	var q byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case q != 0 && s[i] == '\\' && q == '"':
+			i++
+		case q != 0 && s[i] == q:
+			q = 0
+		case q != 0:
+		case s[i] == '"' || s[i] == '\'':
+			q = s[i]
+		case s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// brackets returns balance of square brackets in s outside of quotes and
+// comments.
+func brackets(s string) (n int) {
+	var q byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case q != 0 && s[i] == '\\' && q == '"':
+			i++
+		case q != 0 && s[i] == q:
+			q = 0
+		case q != 0:
+		case s[i] == '"' || s[i] == '\'':
+			q = s[i]
+		case s[i] == '#':
+			return n
+		case s[i] == '[':
+			n++
+		case s[i] == ']':
+			n--
+		}
+	}
+	return n
+}
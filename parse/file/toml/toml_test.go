@@ -3,15 +3,24 @@ package toml
 import (
 	"bytes"
 	"context"
+	"errors"
+	"flag"
 	"testing"
 
 	"github.com/BurntSushi/toml"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/gobwas/flagutil/parse"
 	"github.com/gobwas/flagutil/parse/file"
 	"github.com/gobwas/flagutil/parse/testutil"
 )
 
+var (
+	_ file.Encoder        = new(Syntax)
+	_ file.PositionSyntax = new(Syntax)
+)
+
 func TestTOML(t *testing.T) {
 	testutil.TestParser(t, func(values testutil.Values, fs parse.FlagSet) error {
 		p := file.Parser{
@@ -30,3 +39,124 @@ func marshal(values testutil.Values) []byte {
 	}
 	return buf.Bytes()
 }
+
+func TestSyntaxPositions(t *testing.T) {
+	src := []byte(`# comment
+port = 4050
+tags = [
+  "a = b",
+]
+text = """
+multi = line
+"""
+
+[database]
+endpoint = "localhost"
+"quoted.key" = 1
+pool.size = 10
+
+[http.server]
+  timeout = "1s" # comment
+`)
+	act, err := new(Syntax).Positions(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]file.Position{
+		"port":                {Line: 2, Column: 1},
+		"tags":                {Line: 3, Column: 1},
+		"text":                {Line: 6, Column: 1},
+		"database":            {Line: 10, Column: 1},
+		"database.endpoint":   {Line: 11, Column: 1},
+		"database.quoted.key": {Line: 12, Column: 1},
+		"database.pool":       {Line: 13, Column: 1},
+		"database.pool.size":  {Line: 13, Column: 1},
+		"http":                {Line: 15, Column: 1},
+		"http.server":         {Line: 15, Column: 1},
+		"http.server.timeout": {Line: 16, Column: 3},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected positions:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestSyntaxPositionsMultiline(t *testing.T) {
+	src := []byte(`timeout = "1s"
+text = '''
+[http]
+port = 1
+'''
+
+[[servers]]
+host = "a"
+port = 1
+
+[servers.meta]
+name = "a"
+
+[[servers]]
+host = "b"
+
+[http]
+port = 80
+`)
+	act, err := new(Syntax).Positions(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]file.Position{
+		"timeout":   {Line: 1, Column: 1},
+		"text":      {Line: 2, Column: 1},
+		"servers":   {Line: 7, Column: 1},
+		"http":      {Line: 17, Column: 1},
+		"http.port": {Line: 18, Column: 1},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected positions:\n%s", cmp.Diff(exp, act))
+	}
+	var m map[string]interface{}
+	if err := toml.Unmarshal(src, &m); err != nil {
+		t.Fatalf("test source is not valid: %v", err)
+	}
+}
+
+func TestParserStrictFallback(t *testing.T) {
+	flags := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	flags.Int("port", 0, "")
+	flags.String("db.host", "", "")
+	p := file.Parser{
+		Lookup: file.BytesLookup(`port = 1
+db = { host = "x", bad = 1 }
+
+[[servers]]
+host = "a"
+`),
+		Syntax: new(Syntax),
+		Strict: true,
+	}
+	err := p.Parse(context.Background(), parse.NewFlagSet(flags))
+	var errs file.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want file.Errors; got %v", err)
+	}
+	var act []string
+	for _, e := range errs {
+		act = append(act, e.Key+" "+e.Position.String())
+	}
+	// Keys of inline tables and arrays of tables are reported at the
+	// position of the closest parent key.
+	exp := []string{
+		"db.bad 2:1",
+		"servers 4:1",
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected errors:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestSyntaxPositionsError(t *testing.T) {
+	_, err := new(Syntax).Positions([]byte("a = 1\nb = [\n  1,\n"))
+	if err == nil {
+		t.Fatalf("want error; got nil")
+	}
+}
@@ -1,6 +1,17 @@
 package yaml
 
-import yaml "gopkg.in/yaml.v2"
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
 
 type Syntax struct {
 }
@@ -9,3 +20,195 @@ func (s *Syntax) Unmarshal(p []byte) (m map[string]interface{}, err error) {
 	err = yaml.Unmarshal(p, &m)
 	return
 }
+
+func (s *Syntax) Marshal(m map[string]interface{}) ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// Positions implements file.PositionSyntax interface.
+//
+// Positions scans the source line by line instead of parsing it completely.
+// Only keys of block mappings are reported. Keys within flow collections,
+// sequences (including sequences of mappings) and complex keys are not
+// reported, so position of the closest parent key is used for them. Values
+// of merge keys ("<<") are not followed. Multi-line block, quoted and flow
+// values are skipped; an error is returned if the end of such value can not
+// be found.
+func (s *Syntax) Positions(p []byte) (map[string]file.Position, error) {
+	type level struct {
+		indent int
+		key    string
+	}
+	var (
+		pos   = make(map[string]file.Position)
+		stack []level
+		// skip is indentation of the block scalar or sequence being skipped.
+		skip = -1
+		// flow holds state of the multi-line quoted or flow value being
+		// skipped; start is the line it starts at.
+		flow  scanner
+		start int
+	)
+	for i, line := range bytes.Split(p, []byte{'\n'}) {
+		text := strings.TrimRight(string(line), " \t\r")
+		if flow.open() {
+			flow.scan(text)
+			continue
+		}
+		trim := strings.TrimLeft(text, " ")
+		indent := len(text) - len(trim)
+		if skip != -1 {
+			if trim == "" || indent > skip || (indent == skip && isItem(trim)) {
+				continue
+			}
+			skip = -1
+		}
+		if trim == "" || trim[0] == '#' || trim == "---" || trim == "..." {
+			continue
+		}
+		if isItem(trim) {
+			skip = indent
+			continue
+		}
+		if trim[0] == '{' || trim[0] == '[' {
+			if flow.scan(trim); flow.open() {
+				start = i + 1
+			}
+			continue
+		}
+		if trim[0] == '?' {
+			continue
+		}
+		key, value, ok := splitKey(trim)
+		if !ok {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		var parent string
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].key
+		}
+		k := parse.Join(parent, key)
+		pos[k] = file.Position{
+			Line:   i + 1,
+			Column: utf8.RuneCountInString(text[:indent]) + 1,
+		}
+		stack = append(stack, level{indent, k})
+		if value == "" {
+			continue
+		}
+		switch value[0] {
+		case '|', '>':
+			skip = indent
+		case '"', '\'', '[', '{':
+			if flow.scan(value); flow.open() {
+				start = i + 1
+			}
+		}
+	}
+	if flow.open() {
+		return nil, fmt.Errorf("yaml: can't find end of the value at line %d", start)
+	}
+	return pos, nil
+}
+
+// isItem reports whether trimmed line s starts a sequence item.
+func isItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ") || strings.HasPrefix(s, "-\t")
+}
+
+// scanner tracks quotes and brackets of values spanning multiple lines.
+type scanner struct {
+	quote byte
+	depth int
+}
+
+func (s *scanner) open() bool {
+	return s.quote != 0 || s.depth > 0
+}
+
+// scan updates scanner state with the next piece of the value.
+func (s *scanner) scan(v string) {
+	// Quote starts a scalar only at the beginning of the flow token.
+	token := true
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case s.quote == '"' && c == '\\':
+			i++
+		case s.quote == '\'' && c == '\'' && i+1 < len(v) && v[i+1] == '\'':
+			i++
+		case s.quote != 0 && c == s.quote:
+			s.quote = 0
+			token = false
+		case s.quote != 0:
+		case c == '#' && (i == 0 || v[i-1] == ' ' || v[i-1] == '\t'):
+			return
+		case token && (c == '"' || c == '\''):
+			s.quote = c
+		case c == '[' || c == '{':
+			s.depth++
+			token = true
+		case (c == ']' || c == '}') && s.depth > 0:
+			s.depth--
+			token = false
+		case c == ',' || c == ':' || c == ' ' || c == '\t':
+			token = true
+		default:
+			token = false
+		}
+	}
+}
+
+// splitKey splits the mapping entry line into key and value.
+func splitKey(s string) (key, value string, ok bool) {
+	if s[0] == '"' || s[0] == '\'' {
+		q := s[0]
+		i := 1
+		for ; i < len(s); i++ {
+			if s[i] == '\\' && q == '"' {
+				i++
+				continue
+			}
+			if s[i] == '\'' && q == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				// Escaped single quote.
+				i++
+				continue
+			}
+			if s[i] == q {
+				break
+			}
+		}
+		if i >= len(s) {
+			return "", "", false
+		}
+		key, s = unquote(s[:i+1]), s[i+1:]
+		if len(s) == 0 || s[0] != ':' {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(s[1:]), true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t') {
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), true
+		}
+		if s[i] == '#' && i > 0 && (s[i-1] == ' ' || s[i-1] == '\t') {
+			break
+		}
+	}
+	return "", "", false
+}
+
+// unquote returns the value of the quoted scalar s. Escape sequences of the
+// double-quoted scalar which are not known to Go are left as is.
+func unquote(s string) string {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if v, err := strconv.Unquote(s); err == nil {
+		return v
+	}
+	return s[1 : len(s)-1]
+}
@@ -6,11 +6,18 @@ import (
 
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/gobwas/flagutil/parse"
 	"github.com/gobwas/flagutil/parse/file"
 	"github.com/gobwas/flagutil/parse/testutil"
 )
 
+var (
+	_ file.Encoder        = new(Syntax)
+	_ file.PositionSyntax = new(Syntax)
+)
+
 func TestYAML(t *testing.T) {
 	testutil.TestParser(t, func(values testutil.Values, fs parse.FlagSet) error {
 		p := file.Parser{
@@ -28,3 +35,95 @@ func marshal(values testutil.Values) []byte {
 	}
 	return bts
 }
+
+func TestSyntaxPositions(t *testing.T) {
+	src := []byte(`# comment
+port: 4050
+database:
+  endpoint: localhost # comment
+  "quoted key": 1
+  'it''s': 2
+  "esc\"aped": 3
+  tags:
+    - a
+    - b: c
+  script: |
+    key: value
+  inline: {a: 1}
+http:
+  timeout: 1s
+`)
+	act, err := new(Syntax).Positions(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]file.Position{
+		"port":                {Line: 2, Column: 1},
+		"database":            {Line: 3, Column: 1},
+		"database.endpoint":   {Line: 4, Column: 3},
+		"database.quoted key": {Line: 5, Column: 3},
+		"database.it's":       {Line: 6, Column: 3},
+		"database.esc\"aped":  {Line: 7, Column: 3},
+		"database.tags":       {Line: 8, Column: 3},
+		"database.script":     {Line: 11, Column: 3},
+		"database.inline":     {Line: 13, Column: 3},
+		"http":                {Line: 14, Column: 1},
+		"http.timeout":        {Line: 15, Column: 3},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected positions:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestSyntaxPositionsMultiline(t *testing.T) {
+	src := []byte(`servers:
+  - host: a
+    port: 1
+  - host: b
+    port: 2
+timeout: 1s
+list:
+- name: x
+  value: y
+message: "first line
+  port: not a key"
+quoted: 'it''s
+  host: not a key'
+flow: {a: 1,
+  b: "}"}
+tags: [
+  "x: y",
+]
+http:
+  port: 80
+`)
+	act, err := new(Syntax).Positions(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]file.Position{
+		"servers":   {Line: 1, Column: 1},
+		"timeout":   {Line: 6, Column: 1},
+		"list":      {Line: 7, Column: 1},
+		"message":   {Line: 10, Column: 1},
+		"quoted":    {Line: 12, Column: 1},
+		"flow":      {Line: 14, Column: 1},
+		"tags":      {Line: 16, Column: 1},
+		"http":      {Line: 19, Column: 1},
+		"http.port": {Line: 20, Column: 3},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected positions:\n%s", cmp.Diff(exp, act))
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(src, &m); err != nil {
+		t.Fatalf("test source is not valid: %v", err)
+	}
+}
+
+func TestSyntaxPositionsError(t *testing.T) {
+	_, err := new(Syntax).Positions([]byte("a: 1\nb: \"unterminated\n"))
+	if err == nil {
+		t.Fatalf("want error; got nil")
+	}
+}
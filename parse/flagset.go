@@ -1,10 +1,15 @@
 package parse
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 )
 
+// ErrUndefined is returned by FlagSet.Set() when flag with given name is not
+// defined.
+var ErrUndefined = errors.New("flag provided but not defined")
+
 type FlagGetter interface {
 	Lookup(name string) *flag.Flag
 	VisitAll(func(*flag.Flag))
@@ -20,6 +25,18 @@ type FlagSet interface {
 	FlagSetter
 }
 
+// AliasValue is an optional interface of flag.Value which makes the flag to
+// be an alias of another flag within the same set. Setting value of the alias
+// is expected to set value of the flag it refers to.
+//
+// FlagSet created by NewFlagSet() treats the alias and its target as the
+// same flag. That is, alias value is not set if the target flag is already
+// specified by the previous levels and vice versa.
+type AliasValue interface {
+	flag.Value
+	AliasOf() string
+}
+
 type FlagSetOption func(*flagSet)
 
 func WithIgnoreUndefined(v bool) FlagSetOption {
@@ -31,14 +48,151 @@ func WithIgnoreUndefined(v bool) FlagSetOption {
 func NextLevel(fs FlagSet) {
 	fset := fs.(*flagSet)
 	fset.stash = nil
+	fset.intercept = nil
+	fset.source = Source{}
+	fset.naming = ""
 	fset.update()
 }
 
+// Source describes where the flag value came from.
+type Source struct {
+	// Parser is a parser which provided the value.
+	Parser interface{}
+
+	// Location is an optional parser specific location of the value within
+	// its source, such as path to the configuration file.
+	Location string
+}
+
+// SetSource makes fs to record that all further flag values are provided by
+// given parser p.
+func SetSource(fs FlagSet, p interface{}) {
+	fset := fs.(*flagSet)
+	fset.source = Source{
+		Parser: p,
+	}
+	fset.naming = ""
+}
+
+// SetLocation makes fs to record that all further flag values are provided
+// from given location of the current parser source.
+// It does nothing if fs was not created by NewFlagSet().
+func SetLocation(fs FlagSet, location string) {
+	fset, ok := fs.(*flagSet)
+	if !ok {
+		return
+	}
+	fset.source.Location = location
+}
+
+// SetNaming makes fs to record that all further flag values are given under
+// the name naming, such as "-p" or "$APP_PORT". Naming is reported by errors
+// collected by fs (see SetError).
+// It does nothing if fs was not created by NewFlagSet().
+func SetNaming(fs FlagSet, naming string) {
+	fset, ok := fs.(*flagSet)
+	if !ok {
+		return
+	}
+	fset.naming = naming
+}
+
+// LookupSource returns source of the flag value with given name.
+// It returns false if flag value was not set through fs.
+func LookupSource(fs FlagSet, name string) (Source, bool) {
+	fset := fs.(*flagSet)
+	src, has := fset.sources[name]
+	return src, has
+}
+
+// LookupNaming returns the name the flag value with given name was given
+// under (see SetNaming()). It returns false if flag value was not set through
+// fs or parser didn't provide the naming.
+// It returns false if fs was not created by NewFlagSet().
+func LookupNaming(fs FlagSet, name string) (string, bool) {
+	fset, ok := fs.(*flagSet)
+	if !ok {
+		return "", false
+	}
+	naming, has := fset.namings[name]
+	return naming, has
+}
+
 func Stash(fs FlagSet, fn func(*flag.Flag) bool) {
 	fset := fs.(*flagSet)
 	fset.stash = fn
 }
 
+// Intercept makes fs to pass every flag value being set to fn before setting
+// it. If fn returns true, the value is not set to the destination flag, but
+// the flag is still treated as specified by the current level, and its source
+// is recorded. It is up to fn to set the value later.
+//
+// Interception is reset by NextLevel().
+func Intercept(fs FlagSet, fn func(name, value string) (bool, error)) {
+	fset := fs.(*flagSet)
+	fset.intercept = fn
+}
+
+// Convert makes fs to pass every flag value through fn right before setting
+// it to the destination flag. Value returned by fn is used instead of the
+// original one.
+//
+// Unlike Intercept(), conversion is not reset by NextLevel().
+func Convert(fs FlagSet, fn func(name, value string) (string, error)) {
+	fset := fs.(*flagSet)
+	fset.convert = fn
+}
+
+// SetError describes an error of setting flag value collected by fs when
+// CollectErrors() is enabled.
+type SetError struct {
+	// Name is a name of the flag.
+	Name string
+
+	// Value is a raw value being set.
+	Value string
+
+	// Source is a source of the value.
+	Source Source
+
+	// Naming is a name the value was given under (see SetNaming()). It is
+	// empty if parser didn't provide it.
+	Naming string
+
+	// Err is an error returned by FlagSet.Set().
+	Err error
+}
+
+func (e *SetError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SetError) Unwrap() error {
+	return e.Err
+}
+
+// CollectErrors makes fs to not fail on errors of setting flag values, but to
+// collect them instead. Collected errors are returned by Errors().
+func CollectErrors(fs FlagSet, collect bool) {
+	fset := fs.(*flagSet)
+	fset.collect = collect
+}
+
+// CollectingErrors reports whether fs collects errors of setting flag values
+// (see CollectErrors()).
+// It returns false if fs was not created by NewFlagSet().
+func CollectingErrors(fs FlagSet) bool {
+	fset, ok := fs.(*flagSet)
+	return ok && fset.collect
+}
+
+// Errors returns errors collected by fs in the order of their occurrence.
+func Errors(fs FlagSet) []*SetError {
+	fset := fs.(*flagSet)
+	return fset.errors
+}
+
 func IgnoreUndefined(fs FlagSet, ignore bool) {
 	fset := fs.(*flagSet)
 	fset.ignoreUndefined = ignore
@@ -55,12 +209,24 @@ type flagSet struct {
 	allowResetSpecified bool
 	specified           map[string]bool
 	stash               func(*flag.Flag) bool
+	intercept           func(name, value string) (bool, error)
+	intercepted         map[string]bool
+	convert             func(name, value string) (string, error)
+	source              Source
+	naming              string
+	sources             map[string]Source
+	namings             map[string]string
+	collect             bool
+	errors              []*SetError
 }
 
 func NewFlagSet(flags *flag.FlagSet, opts ...FlagSetOption) FlagSet {
 	fs := &flagSet{
-		dest:      flags,
-		specified: make(map[string]bool),
+		dest:        flags,
+		specified:   make(map[string]bool),
+		intercepted: make(map[string]bool),
+		sources:     make(map[string]Source),
+		namings:     make(map[string]string),
 	}
 	for _, opt := range opts {
 		opt(fs)
@@ -70,7 +236,54 @@ func NewFlagSet(flags *flag.FlagSet, opts ...FlagSetOption) FlagSet {
 }
 
 func (fs *flagSet) Set(name, value string) error {
-	if fs.specified[name] && !fs.allowResetSpecified {
+	err := fs.set(name, value)
+	if err != nil && fs.collect {
+		fs.collectError(name, value, err)
+		return nil
+	}
+	return err
+}
+
+// ReportError reports err related to the flag with given name, which is not
+// an error of setting its value, such as failure of reading the value from
+// its source. If fs collects errors (see CollectErrors()), err is collected
+// as SetError having the current source and naming, and nil is returned.
+// Otherwise err is returned as is.
+func ReportError(fs FlagSet, name string, err error) error {
+	fset, ok := fs.(*flagSet)
+	if !ok || !fset.collect || err == nil {
+		return err
+	}
+	fset.collectError(name, "", err)
+	return nil
+}
+
+func (fs *flagSet) collectError(name, value string, err error) {
+	fs.errors = append(fs.errors, &SetError{
+		Name:   name,
+		Value:  value,
+		Source: fs.source,
+		Naming: fs.naming,
+		Err:    err,
+	})
+}
+
+// record records source and naming of the value of the flag with given name
+// and of its alias target.
+func (fs *flagSet) record(name, target string) {
+	for _, n := range [...]string{name, target} {
+		fs.sources[n] = fs.source
+		if fs.naming != "" {
+			fs.namings[n] = fs.naming
+		} else {
+			delete(fs.namings, n)
+		}
+	}
+}
+
+func (fs *flagSet) set(name, value string) error {
+	target := fs.target(name)
+	if (fs.specified[name] || fs.specified[target]) && !fs.allowResetSpecified {
 		return nil
 	}
 	f := fs.dest.Lookup(name)
@@ -82,13 +295,44 @@ func (fs *flagSet) Set(name, value string) error {
 		return nil
 	}
 	if !defined {
-		return fmt.Errorf("flag provided but not defined: %q", name)
+		return fmt.Errorf("%w: %q", ErrUndefined, name)
+	}
+	if fs.intercept != nil {
+		ok, err := fs.intercept(name, value)
+		if err != nil {
+			return fmt.Errorf("set %q: %w", name, err)
+		}
+		if ok {
+			fs.intercepted[name] = true
+			fs.intercepted[target] = true
+			fs.record(name, target)
+			return nil
+		}
+	}
+	if fs.convert != nil {
+		v, err := fs.convert(name, value)
+		if err != nil {
+			return fmt.Errorf("set %q: %w", name, err)
+		}
+		value = v
 	}
 	err := fs.dest.Set(name, value)
 	if err != nil {
-		err = fmt.Errorf("set %q: %w", name, err)
+		return fmt.Errorf("set %q: %w", name, err)
 	}
-	return err
+	fs.record(name, target)
+	return nil
+}
+
+// target returns name of the flag which given flag is alias of. It returns
+// name itself if flag is not an alias.
+func (fs *flagSet) target(name string) string {
+	if f := fs.dest.Lookup(name); f != nil {
+		if a, ok := f.Value.(AliasValue); ok {
+			return a.AliasOf()
+		}
+	}
+	return name
 }
 
 func (fs *flagSet) stashed(f *flag.Flag) bool {
@@ -100,11 +344,14 @@ func (fs *flagSet) update() {
 	fs.dest.Visit(func(f *flag.Flag) {
 		fs.specified[f.Name] = true
 	})
+	for name := range fs.intercepted {
+		fs.specified[name] = true
+	}
 }
 
 func (fs *flagSet) VisitUnspecified(fn func(*flag.Flag)) {
 	fs.dest.VisitAll(func(f *flag.Flag) {
-		if !fs.specified[f.Name] && !fs.stashed(f) {
+		if !fs.specified[f.Name] && !fs.specified[fs.target(f.Name)] && !fs.stashed(f) {
 			fn(fs.clone(f))
 		}
 	})
@@ -0,0 +1,64 @@
+package parse
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// HelpError is an error returned by parsers to request help message with
+// additional parameters. It is treated as flag.ErrHelp by errors.Is().
+type HelpError struct {
+	// All requests help message describing all flags, including the hidden
+	// ones.
+	All bool
+
+	// Subset requests help message describing only flags of the subset with
+	// given prefix (e.g. "--help=database").
+	Subset string
+}
+
+// Error implements error interface.
+func (e *HelpError) Error() string {
+	return flag.ErrHelp.Error()
+}
+
+// Is makes HelpError to match flag.ErrHelp.
+func (e *HelpError) Is(err error) bool {
+	return err == flag.ErrHelp
+}
+
+// Help returns help request error for the flag with given name and value.
+// It returns nil if name is not a name of the help flag: "h", "help" or
+// "help-all".
+//
+// Boolean true value (or empty string) requests help message for all flags.
+// Help flag can not be set to false; an error which is not a help request is
+// returned in that case. Any other value is treated as a name of the flag
+// subset to describe.
+func Help(name, value string) error {
+	var all bool
+	switch name {
+	case "h", "help":
+	case "help-all":
+		all = true
+	default:
+		return nil
+	}
+	if value == "" {
+		value = "true"
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		if !b {
+			return fmt.Errorf("invalid value %q for help flag %q", value, name)
+		}
+		if !all {
+			return flag.ErrHelp
+		}
+		value = ""
+	}
+	return &HelpError{
+		All:    all,
+		Subset: value,
+	}
+}
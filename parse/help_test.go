@@ -0,0 +1,51 @@
+package parse
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestHelp(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		value  string
+		help   bool
+		all    bool
+		subset string
+		err    bool
+	}{
+		{name: "h", help: true},
+		{name: "help", value: "true", help: true},
+		{name: "help", value: "database", help: true, subset: "database"},
+		{name: "help-all", help: true, all: true},
+		{name: "help-all", value: "true", help: true, all: true},
+		{name: "help-all", value: "http", help: true, all: true, subset: "http"},
+		{name: "help", value: "1", help: true},
+		{name: "help", value: "false", err: true},
+		{name: "help-all", value: "0", err: true},
+		{name: "helpall"},
+		{name: "foo", value: "true"},
+	} {
+		t.Run(test.name+"="+test.value, func(t *testing.T) {
+			err := Help(test.name, test.value)
+			if act, exp := err != nil && !errors.Is(err, flag.ErrHelp), test.err; act != exp {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if act, exp := errors.Is(err, flag.ErrHelp), test.help; act != exp {
+				t.Fatalf("unexpected help error: %v", err)
+			}
+			var h *HelpError
+			if act, exp := errors.As(err, &h) && h.All, test.all; act != exp {
+				t.Errorf("unexpected all flag: %t; want %t", act, exp)
+			}
+			var subset string
+			if h != nil {
+				subset = h.Subset
+			}
+			if act, exp := subset, test.subset; act != exp {
+				t.Errorf("unexpected subset: %q; want %q", act, exp)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ type Parser struct {
 	pos   int
 	err   error
 	mult  bool
+	short bool
 	name  string
 	value string
 	fs    parse.FlagSet
@@ -41,11 +42,12 @@ func (p *Parser) Parse(_ context.Context, fs parse.FlagSet) (err error) {
 
 	for p.next() {
 		p.pairs(func(name, value string) bool {
+			parse.SetNaming(fs, p.dash()+name)
 			name = p.resolve(name)
 
 			_, isHelp := lookup(fs, name)
 			if isHelp {
-				err = flag.ErrHelp
+				err = parse.Help(name, value)
 				return false
 			}
 
@@ -67,6 +69,11 @@ func (p *Parser) NonOptionArgs() []string {
 	return nil
 }
 
+// Kind implements flagutil.KindParser interface.
+func (p *Parser) Kind() string {
+	return "posix"
+}
+
 func (p *Parser) resolve(name string) string {
 	if s, has := p.alias[name]; has {
 		name = s
@@ -131,7 +138,7 @@ func (p *Parser) reset(fs parse.FlagSet) {
 
 func lookup(fs parse.FlagSet, name string) (f *flag.Flag, isHelp bool) {
 	f = fs.Lookup(name)
-	isHelp = f == nil && (name == "h" || name == "help")
+	isHelp = f == nil && parse.Help(name, "") != nil
 	return
 }
 
@@ -224,12 +231,21 @@ func (p *Parser) next() bool {
 		return false
 	}
 
+	p.short = short
 	p.name = name
 	p.value = value
 
 	return true
 }
 
+// dash returns prefix of the current option name.
+func (p *Parser) dash() string {
+	if p.short {
+		return "-"
+	}
+	return "--"
+}
+
 func (p *Parser) shorthands(fs parse.FlagSet) map[string]string {
 	short := make(map[string]string)
 	// Need to provide all shorthand aliases to not fail on meeting some
@@ -2,6 +2,9 @@ package pargs
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -338,6 +341,32 @@ func TestPosixParse(t *testing.T) {
 	}
 }
 
+func TestPosixParseHelp(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		all  bool
+	}{
+		{args: []string{"-h"}},
+		{args: []string{"--help"}},
+		{args: []string{"--help-all"}, all: true},
+	} {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var fs testutil.StubFlagSet
+			p := Parser{
+				Args: test.args,
+			}
+			err := p.Parse(context.Background(), &fs)
+			if !errors.Is(err, flag.ErrHelp) {
+				t.Fatalf("want help error; got %v", err)
+			}
+			var h *parse.HelpError
+			if act, exp := errors.As(err, &h) && h.All, test.all; act != exp {
+				t.Errorf("unexpected all flag: %t; want %t", act, exp)
+			}
+		})
+	}
+}
+
 func TestPosix(t *testing.T) {
 	testutil.TestParser(t, func(values testutil.Values, fs parse.FlagSet) error {
 		p := Parser{
@@ -366,3 +395,27 @@ func marshal(values testutil.Values) (args []string) {
 	})
 	return args
 }
+
+func TestPosixCollectErrorsNaming(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.Bool("verbose", false, "")
+	err := flagutil.Parse(context.Background(), fs,
+		flagutil.WithParser(&Parser{
+			Args:      []string{"--port=x", "-p", "y", "-v"},
+			Shorthand: true,
+		}),
+		flagutil.WithCollectErrors(),
+	)
+	var errs flagutil.ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want ParseErrors; got %v", err)
+	}
+	var act []string
+	for _, e := range errs {
+		act = append(act, e.Naming)
+	}
+	if exp := []string{"--port", "-p"}; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected namings:\n%s", cmp.Diff(exp, act))
+	}
+}
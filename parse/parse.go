@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 var SetSeparator = "."
@@ -28,11 +29,110 @@ func (v VisitorFunc) Has(name string) bool {
 	return v.HasFunc(name)
 }
 
-func Setup(x interface{}, v Visitor) error {
-	return setup(v, "", x)
+// SetupOption configures Setup() and SetupAll().
+type SetupOption func(*setupState)
+
+// WithEmptyValues makes Setup() and SetupAll() set empty string values.
+// By default empty string values are rejected. It is useful for the syntaxes
+// which values are always strings (such as .env files) where `KEY=` means
+// an empty value.
+func WithEmptyValues() SetupOption {
+	return func(s *setupState) {
+		s.empty = true
+	}
+}
+
+func Setup(x interface{}, v Visitor, opts ...SetupOption) error {
+	var s setupState
+	s.visitor = v
+	for _, opt := range opts {
+		opt(&s)
+	}
+	s.setup("", x)
+	if len(s.errs) > 0 {
+		return s.errs[0]
+	}
+	return nil
+}
+
+// SetupAll is the same as Setup() but it does not stop on the first error.
+// It returns SetupErrors holding all errors occurred.
+func SetupAll(x interface{}, v Visitor, opts ...SetupOption) error {
+	s := setupState{
+		visitor: v,
+		all:     true,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	s.setup("", x)
+	if len(s.errs) > 0 {
+		return s.errs
+	}
+	return nil
+}
+
+// SetupError describes an error occurred during Setup().
+type SetupError struct {
+	// Key is a name of the flag which value was being set.
+	Key string
+
+	// Value is a string representation of the value. It is empty if value
+	// can't be represented as a string.
+	Value string
+
+	// Err is an underlying error.
+	Err error
+
+	raw interface{}
 }
 
-func setup(v Visitor, key string, value interface{}) error {
+func (e *SetupError) Error() string {
+	if e.raw == nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf(
+		"set %q (%T) as flag %q value error: %v",
+		e.Value, e.raw, e.Key, e.Err,
+	)
+}
+
+func (e *SetupError) Unwrap() error {
+	return e.Err
+}
+
+// SetupErrors holds all errors occurred during SetupAll().
+type SetupErrors []*SetupError
+
+func (es SetupErrors) Error() string {
+	if len(es) == 1 {
+		return es[0].Error()
+	}
+	var sb strings.Builder
+	sb.WriteString("setup failed:")
+	for _, e := range es {
+		sb.WriteString("\n  ")
+		sb.WriteString(e.Error())
+	}
+	return sb.String()
+}
+
+type setupState struct {
+	visitor Visitor
+	all     bool
+	empty   bool
+	errs    SetupErrors
+}
+
+// fail records an error. It returns true if setup must be stopped.
+func (s *setupState) fail(err *SetupError) bool {
+	s.errs = append(s.errs, err)
+	return !s.all
+}
+
+// setup sets up value at given key. It returns false if setup must be
+// stopped.
+func (s *setupState) setup(key string, value interface{}) bool {
 	var (
 		typ = reflect.TypeOf(value)
 		val = reflect.ValueOf(value)
@@ -40,28 +140,37 @@ func setup(v Visitor, key string, value interface{}) error {
 	switch typ.Kind() {
 	case reflect.Map:
 		iter := val.MapRange()
-		if v.Has(key) {
+		if s.visitor.Has(key) {
 			for iter.Next() {
 				ks, err := stringify(iter.Key().Interface())
 				if err != nil {
-					return err
+					if s.fail(&SetupError{Key: key, Err: err}) {
+						return false
+					}
+					continue
 				}
 				vs, err := stringify(iter.Value().Interface())
 				if err != nil {
-					return err
+					if s.fail(&SetupError{Key: key, Err: err}) {
+						return false
+					}
+					continue
 				}
-				if err := setup(v, key, ks+":"+vs); err != nil {
-					return err
+				if !s.setup(key, ks+":"+vs) {
+					return false
 				}
 			}
 		} else {
 			for iter.Next() {
 				ks, err := stringify(iter.Key().Interface())
 				if err != nil {
-					return err
+					if s.fail(&SetupError{Key: key, Err: err}) {
+						return false
+					}
+					continue
 				}
-				if err := setup(v, Join(key, ks), iter.Value().Interface()); err != nil {
-					return err
+				if !s.setup(Join(key, ks), iter.Value().Interface()) {
+					return false
 				}
 			}
 		}
@@ -70,36 +179,53 @@ func setup(v Visitor, key string, value interface{}) error {
 		for i := 0; i < val.Len(); i++ {
 			vs, err := stringify(val.Index(i).Interface())
 			if err != nil {
-				return err
+				if s.fail(&SetupError{Key: key, Err: err}) {
+					return false
+				}
+				continue
 			}
-			if err := setup(v, key, vs); err != nil {
-				return err
+			if !s.setup(key, vs) {
+				return false
 			}
 		}
 
 	default:
 		str, err := stringify(value)
 		if err != nil {
-			return err
+			return !s.fail(&SetupError{Key: key, Err: err})
 		}
-		if str == "" {
-			return fmt.Errorf("can't use empty key as flag name")
+		if key == "" {
+			return !s.fail(&SetupError{
+				Value: str,
+				Err:   fmt.Errorf("can't use empty key as flag name"),
+				raw:   value,
+			})
 		}
-		if err := v.Set(key, str); err != nil {
-			return fmt.Errorf(
-				"set %q (%T) as flag %q value error: %v",
-				str, value, key, err,
-			)
+		if str == "" && !s.empty {
+			return !s.fail(&SetupError{
+				Key: key,
+				Err: fmt.Errorf("can't use empty value"),
+			})
+		}
+		if err := s.visitor.Set(key, str); err != nil {
+			return !s.fail(&SetupError{
+				Key:   key,
+				Value: str,
+				Err:   err,
+				raw:   value,
+			})
 		}
 	}
-	return nil
+	return true
 }
 
+// Join joins key path elements a and b with SetSeparator. It returns b if a
+// is empty.
 func Join(a, b string) string {
 	if a == "" {
 		return b
 	}
-	return a + "." + b
+	return a + SetSeparator + b
 }
 
 func stringify(x interface{}) (string, error) {
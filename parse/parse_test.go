@@ -1,6 +1,9 @@
 package parse
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -11,6 +14,7 @@ func TestSetup(t *testing.T) {
 		name  string
 		pairs [][2]string
 		input interface{}
+		opts  []SetupOption
 		has   map[string]bool
 		err   bool
 	}{
@@ -72,6 +76,32 @@ func TestSetup(t *testing.T) {
 				{"foo.bar", "baz:yes"},
 			},
 		},
+		{
+			name: "empty value",
+			input: map[string]interface{}{
+				"foo": "",
+			},
+			err: true,
+		},
+		{
+			name: "empty value allowed",
+			input: map[string]interface{}{
+				"foo": "",
+			},
+			opts: []SetupOption{
+				WithEmptyValues(),
+			},
+			pairs: [][2]string{
+				{"foo", ""},
+			},
+		},
+		{
+			name: "empty key",
+			input: map[string]interface{}{
+				"": "foo",
+			},
+			err: true,
+		},
 		{
 			name: "restrictions",
 			input: map[string]interface{}{
@@ -94,7 +124,7 @@ func TestSetup(t *testing.T) {
 				HasFunc: func(name string) bool {
 					return test.has[name]
 				},
-			})
+			}, test.opts...)
 			if test.err && err == nil {
 				t.Fatalf("want error; got nothing")
 			}
@@ -107,3 +137,48 @@ func TestSetup(t *testing.T) {
 		})
 	}
 }
+
+func TestSetupAll(t *testing.T) {
+	input := map[string]interface{}{
+		"a": "1",
+		"b": "2",
+		"c": map[string]interface{}{
+			"d": []interface{}{"3", "4"},
+		},
+		"e": []interface{}{
+			map[string]string{},
+		},
+	}
+	fail := map[string]bool{
+		"a":   true,
+		"c.d": true,
+	}
+	var act [][2]string
+	err := SetupAll(input, VisitorFunc{
+		SetFunc: func(name, value string) error {
+			if fail[name] {
+				return fmt.Errorf("bad value")
+			}
+			act = append(act, [2]string{name, value})
+			return nil
+		},
+		HasFunc: func(name string) bool {
+			return false
+		},
+	})
+	if exp := [][2]string{{"b", "2"}}; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected pairs:\n%s", cmp.Diff(exp, act))
+	}
+	var errs SetupErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want SetupErrors; got %v", err)
+	}
+	var keys []string
+	for _, e := range errs {
+		keys = append(keys, e.Key+"="+e.Value)
+	}
+	sort.Strings(keys)
+	if exp := []string{"a=1", "c.d=3", "c.d=4", "e="}; !cmp.Equal(keys, exp) {
+		t.Errorf("unexpected errors:\n%s", cmp.Diff(exp, keys))
+	}
+}
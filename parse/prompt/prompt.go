@@ -73,6 +73,11 @@ func (p *Parser) Parse(ctx context.Context, fs parse.FlagSet) (err error) {
 	return err
 }
 
+// Kind implements flagutil.KindParser interface.
+func (p *Parser) Kind() string {
+	return "prompt"
+}
+
 func (p *Parser) values(ctx context.Context, f *flag.Flag) ([]string, error) {
 	cfg, err := p.info(ctx, f)
 	if err != nil {
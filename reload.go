@@ -0,0 +1,589 @@
+package flagutil
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// Change describes flag value change made by Reloader.
+type Change struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Reloader re-runs parsing pipeline to update flag values of long-running
+// programs.
+//
+// Reload is made against a temporary flag set which only records values
+// given by parsers. Thus every flag can be specified again during reload,
+// while parsers precedence remains the same as for Parse(). Recorded values
+// are set to the copies of the flag values taken before the first parsing.
+// Required flags and validators (see WithRequired() and WithValidator()) are
+// checked against these copies. Copies are applied to the flags only if
+// whole pipeline succeeds and every recorded value is set without error. That
+// is, flag values accumulating multiple Set() calls (such as lists) hold only
+// the values given by the last reload.
+//
+// Values which are pointers to plain data (numbers, strings, and slices, maps
+// and structs of them) or maps of plain data are copied with reflection.
+// Other values must implement ReloadValue interface; otherwise reload fails
+// when they change.
+//
+// Initial parsing must be made by Reloader's Parse() method (or by Reload())
+// instead of Parse() function: values of the flags parsed before the first
+// reload can't be restored, so Reload() fails in that case. Also note that
+// it is caller responsibility to synchronize access to flag values while
+// they are reloaded.
+type Reloader struct {
+	// Flags is a flag set which values are reloaded.
+	Flags *flag.FlagSet
+
+	// Options contains options of parsing. Usually they are the same as
+	// given to Parse().
+	Options []ParseOption
+
+	// Signals contains signals (such as syscall.SIGHUP) which make Watch()
+	// to reload flag values.
+	Signals []os.Signal
+
+	// PollInterval specifies how often files used by file.Parser parsers are
+	// checked for modification by Watch(). Zero means no polling.
+	PollInterval time.Duration
+
+	// OnError is called when reload triggered by Watch() fails.
+	OnError func(error)
+
+	once        sync.Once
+	config      config
+	mu          sync.Mutex
+	subscribers []func([]Change)
+	applied     map[string][]string
+	initial     map[string]ReloadValue
+	parsed      bool
+}
+
+// ReloadValue is an optional interface of flag.Value which makes Reloader
+// able to reload values it can not copy by itself.
+type ReloadValue interface {
+	flag.Value
+
+	// Copy returns a copy of the value which doesn't share any state with
+	// the value.
+	Copy() ReloadValue
+
+	// Assign makes the value to hold the state of v. Value v is always the
+	// one returned by Copy(), possibly modified by Set() calls after that.
+	Assign(v ReloadValue)
+}
+
+func (r *Reloader) init() {
+	r.once.Do(func() {
+		r.config = buildConfig(r.Options)
+		r.applied = make(map[string][]string)
+		r.initial = make(map[string]ReloadValue)
+		r.Flags.VisitAll(func(f *flag.Flag) {
+			if v, ok := reloadValue(f.Value); ok {
+				r.initial[f.Name] = v.Copy()
+			}
+		})
+		r.Flags.Visit(func(*flag.Flag) {
+			r.parsed = true
+		})
+	})
+}
+
+// Subscribe makes fn to be called with changes made by every successful
+// reload. It is not called if no flag value has been changed.
+//
+// Subscribers are called after the reload is complete, so it is safe to call
+// Reload() from fn.
+func (r *Reloader) Subscribe(fn func([]Change)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Parse makes initial parsing of the flags. It is the same as Parse()
+// function called with r.Options, except that it makes further reloads to
+// start from the flag values the program had before parsing. Subscribers are
+// not called.
+//
+// Unlike Reload(), values which can not be copied are set as is.
+func (r *Reloader) Parse(ctx context.Context) error {
+	r.init()
+	r.mu.Lock()
+	_, err := r.reload(ctx, true)
+	r.mu.Unlock()
+	return handleError(ctx, &r.config, r.Flags, err)
+}
+
+// Reload runs parsing pipeline and updates changed flag values.
+// If it returns non-nil error, flag values are left untouched.
+func (r *Reloader) Reload(ctx context.Context) ([]Change, error) {
+	r.init()
+	r.mu.Lock()
+	changes, err := r.reload(ctx, false)
+	subscribers := r.subscribers
+	r.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("flagutil: reload error: %w", err)
+	}
+
+	if len(changes) > 0 {
+		for _, fn := range subscribers {
+			fn(changes)
+		}
+	}
+	return changes, nil
+}
+
+// update holds a new value of the flag.
+type update struct {
+	flag  *flag.Flag
+	dst   ReloadValue
+	value ReloadValue
+}
+
+// reload runs parsing pipeline and applies changed flag values. Values which
+// can not be copied are set as is if initial is true.
+func (r *Reloader) reload(ctx context.Context, initial bool) (changes []Change, err error) {
+	if r.parsed {
+		return nil, fmt.Errorf(
+			"flags were parsed before the first reload; use Reloader.Parse()",
+		)
+	}
+	staging := flag.NewFlagSet(r.Flags.Name(), flag.ContinueOnError)
+	staging.SetOutput(r.Flags.Output())
+	recorders := make(map[string]*recorder)
+	r.Flags.VisitAll(func(f *flag.Flag) {
+		if a, ok := f.Value.(*aliasValue); ok {
+			// Make alias to set value of the target flag's recorder.
+			cp := *a
+			cp.fs = staging
+			staging.Var(&cp, f.Name, f.Usage)
+			return
+		}
+		rec := &recorder{orig: f.Value}
+		recorders[f.Name] = rec
+		staging.Var(rec, f.Name, f.Usage)
+	})
+	var (
+		c       = r.config
+		sources Sources
+	)
+	// Required flags and validators are checked against the new values
+	// below.
+	c.required = nil
+	c.validators = nil
+	c.sources = &sources
+	if err := parseFlags(ctx, &c, staging); err != nil {
+		return nil, err
+	}
+
+	var updates []update
+	r.Flags.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		rec, has := recorders[f.Name]
+		if !has || equal(rec.sets, r.applied[f.Name]) {
+			return
+		}
+		dst, ok := reloadValue(f.Value)
+		base := r.initial[f.Name]
+		if (!ok || base == nil) && initial {
+			for _, s := range rec.sets {
+				if e := f.Value.Set(s); e != nil {
+					err = fmt.Errorf("set %q: %w", f.Name, e)
+					return
+				}
+			}
+			r.applied[f.Name] = rec.sets
+			return
+		}
+		if !ok || base == nil {
+			err = fmt.Errorf(
+				"value of flag %q (%T) can not be copied",
+				f.Name, f.Value,
+			)
+			return
+		}
+		v := base.Copy()
+		for _, s := range rec.sets {
+			if e := v.Set(s); e != nil {
+				err = fmt.Errorf("set %q: %w", f.Name, e)
+				return
+			}
+		}
+		updates = append(updates, update{
+			flag:  f,
+			dst:   dst,
+			value: v,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.check(ctx, recorders, updates, sources); err != nil {
+		return nil, err
+	}
+
+	for _, u := range updates {
+		name := u.flag.Name
+		prev := u.flag.Value.String()
+		u.dst.Assign(u.value)
+		r.applied[name] = recorders[name].sets
+		if s := u.flag.Value.String(); s != prev {
+			changes = append(changes, Change{
+				Name: name,
+				Old:  prev,
+				New:  s,
+			})
+		}
+		message, _, deprecated := deprecation(u.flag)
+		if deprecated && len(recorders[name].sets) > 0 {
+			DeprecationWarning(r.Flags, name, message)
+		}
+	}
+	for name, rec := range recorders {
+		if len(rec.sets) > 0 {
+			SetActual(r.Flags, name)
+		}
+	}
+	if dst := r.config.sources; dst != nil {
+		*dst = sources
+	}
+	return changes, nil
+}
+
+// check checks required flags and runs validators against the values which
+// are going to be applied.
+func (r *Reloader) check(ctx context.Context, recorders map[string]*recorder, updates []update, sources Sources) error {
+	if r.config.required == nil && len(r.config.validators) == 0 {
+		return nil
+	}
+	values := make(map[string]flag.Value, len(updates))
+	for _, u := range updates {
+		values[u.flag.Name] = plainValue(u.value)
+	}
+	next := flag.NewFlagSet(r.Flags.Name(), flag.ContinueOnError)
+	r.Flags.VisitAll(func(f *flag.Flag) {
+		v, has := values[f.Name]
+		if !has {
+			v = f.Value
+		}
+		next.Var(v, f.Name, f.Usage)
+	})
+	for name, rec := range recorders {
+		if len(rec.sets) > 0 {
+			SetActual(next, name)
+		}
+	}
+	if err := checkRequired(ctx, &r.config, next); err != nil {
+		return err
+	}
+	return validate(&r.config, next, sources)
+}
+
+// Watch reloads flag values every time one of r.Signals is received or files
+// used by file.Parser parsers are changed. It blocks until ctx is done.
+func (r *Reloader) Watch(ctx context.Context) error {
+	r.init()
+
+	sig := make(chan os.Signal, 1)
+	if len(r.Signals) > 0 {
+		signal.Notify(sig, r.Signals...)
+		defer signal.Stop(sig)
+	}
+	var (
+		tick  <-chan time.Time
+		state map[string]fileState
+	)
+	if r.PollInterval > 0 {
+		ticker := time.NewTicker(r.PollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+		state = r.filesState()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sig:
+		case <-tick:
+			next := r.filesState()
+			if reflect.DeepEqual(state, next) {
+				continue
+			}
+			state = next
+		}
+		if _, err := r.Reload(ctx); err != nil && r.OnError != nil {
+			r.OnError(err)
+		}
+	}
+}
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (r *Reloader) filesState() map[string]fileState {
+	state := make(map[string]fileState)
+	for _, p := range r.config.parsers {
+		fp, ok := p.Parser.(*file.Parser)
+		if !ok {
+			continue
+		}
+		for _, path := range file.Paths(fp.Lookup) {
+			info, err := os.Stat(path)
+			if err != nil {
+				state[path] = fileState{}
+				continue
+			}
+			state[path] = fileState{
+				exists:  true,
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			}
+		}
+	}
+	return state
+}
+
+// recorder is a flag.Value which only records values being set.
+type recorder struct {
+	orig flag.Value
+	sets []string
+}
+
+func (r *recorder) Set(s string) error {
+	r.sets = append(r.sets, s)
+	return nil
+}
+
+func (r *recorder) String() string {
+	if n := len(r.sets); n > 0 {
+		return r.sets[n-1]
+	}
+	if r.orig == nil {
+		return ""
+	}
+	return r.orig.String()
+}
+
+func (r *recorder) IsBoolFlag() bool {
+	return r.orig != nil && isBoolValue(r.orig)
+}
+
+// reloadValue returns ReloadValue for v. It returns false if v neither
+// implements ReloadValue nor can be copied with reflection.
+func reloadValue(v flag.Value) (ReloadValue, bool) {
+	switch x := v.(type) {
+	case ReloadValue:
+		return x, true
+	case *deprecatedValue:
+		// Deprecation warning is emitted by Reloader itself.
+		return reloadValue(x.Value)
+	}
+	t := reflect.TypeOf(v)
+	if t == nil || !copyable(t, nil) {
+		return nil, false
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return pointerValue{v}, true
+	case reflect.Map:
+		if reflect.ValueOf(v).IsNil() {
+			return nil, false
+		}
+		return mapRef{v}, true
+	}
+	return nil, false
+}
+
+// plainValue returns flag.Value wrapped by v if v was made by reloadValue().
+func plainValue(v ReloadValue) flag.Value {
+	switch x := v.(type) {
+	case pointerValue:
+		return x.Value
+	case mapRef:
+		return x.Value
+	}
+	return v
+}
+
+// pointerValue implements ReloadValue for pointers to plain data.
+type pointerValue struct {
+	flag.Value
+}
+
+func (p pointerValue) Copy() ReloadValue {
+	src := reflect.ValueOf(p.Value)
+	dst := reflect.New(src.Type().Elem())
+	if !src.IsNil() {
+		dst.Elem().Set(deepCopy(src.Elem()))
+	}
+	return pointerValue{dst.Interface().(flag.Value)}
+}
+
+func (p pointerValue) Assign(v ReloadValue) {
+	src := reflect.ValueOf(v.(pointerValue).Value)
+	reflect.ValueOf(p.Value).Elem().Set(src.Elem())
+}
+
+// mapRef implements ReloadValue for maps of plain data. Maps are updated
+// in place, since flag value refers to the same map.
+type mapRef struct {
+	flag.Value
+}
+
+func (m mapRef) Copy() ReloadValue {
+	return mapRef{deepCopy(reflect.ValueOf(m.Value)).Interface().(flag.Value)}
+}
+
+func (m mapRef) Assign(v ReloadValue) {
+	var (
+		dst = reflect.ValueOf(m.Value)
+		src = reflect.ValueOf(v.(mapRef).Value)
+	)
+	for _, k := range dst.MapKeys() {
+		dst.SetMapIndex(k, reflect.Value{})
+	}
+	for iter := src.MapRange(); iter.Next(); {
+		dst.SetMapIndex(iter.Key(), iter.Value())
+	}
+}
+
+// copyable reports whether value of type t can be copied by deepCopy().
+// Seen holds types being checked to break recursion.
+func copyable(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		if seen == nil {
+			seen = make(map[reflect.Type]bool)
+		}
+		seen[t] = true
+		return copyable(t.Elem(), seen)
+	case reflect.Map:
+		return copyable(t.Key(), seen) && copyable(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !isPlain(f.Type) {
+				// Unexported field can only be copied as is.
+				return false
+			}
+			if !copyable(f.Type, seen) {
+				return false
+			}
+		}
+		return true
+	case
+		reflect.Interface,
+		reflect.Func,
+		reflect.Chan,
+		reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+// isPlain reports whether value of type t holds no references.
+func isPlain(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return isPlain(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isPlain(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	case
+		reflect.Ptr,
+		reflect.Slice,
+		reflect.Map,
+		reflect.Interface,
+		reflect.Func,
+		reflect.Chan,
+		reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+// deepCopy returns a copy of v which doesn't share memory with v. Type of v
+// must be copyable().
+func deepCopy(v reflect.Value) reflect.Value {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		cp := reflect.New(t.Elem())
+		cp.Elem().Set(deepCopy(v.Elem()))
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		cp := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(t)
+		}
+		cp := reflect.MakeMapWithSize(t, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			cp.SetMapIndex(deepCopy(iter.Key()), deepCopy(iter.Value()))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(t).Elem()
+		cp.Set(v) // Unexported fields are plain.
+		for i := 0; i < v.NumField(); i++ {
+			if f := cp.Field(i); f.CanSet() {
+				f.Set(deepCopy(v.Field(i)))
+			}
+		}
+		return cp
+	}
+	return v
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,419 @@
+package flagutil
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+	"github.com/gobwas/flagutil/parse/file/json"
+)
+
+func TestReloader(t *testing.T) {
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	writeFile(t, f.Name(), `{"host":"file","port":1}`)
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	var (
+		host = fs.String("host", "", "")
+		port = fs.Int("port", 0, "")
+		name = fs.String("name", "", "")
+	)
+	r := Reloader{
+		Flags: fs,
+		Options: []ParseOption{
+			WithParser(ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+				return fs.Set("host", "cli")
+			})),
+			WithParser(&file.Parser{
+				Lookup: file.PathLookup(f.Name()),
+				Syntax: new(json.Syntax),
+			}),
+		},
+	}
+	var notified []Change
+	r.Subscribe(func(changes []Change) {
+		notified = append(notified, changes...)
+	})
+	if _, err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *host != "cli" || *port != 1 || *name != "" {
+		t.Fatalf("unexpected initial values: %q %d %q", *host, *port, *name)
+	}
+
+	writeFile(t, f.Name(), `{"host":"file","port":2,"name":"foo"}`)
+	changes, err := r.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []Change{
+		{Name: "name", Old: "", New: "foo"},
+		{Name: "port", Old: "1", New: "2"},
+	}
+	if !cmp.Equal(changes, exp) {
+		t.Errorf("unexpected changes:\n%s", cmp.Diff(exp, changes))
+	}
+	if *host != "cli" {
+		t.Errorf("unexpected override of higher priority value: %q", *host)
+	}
+
+	writeFile(t, f.Name(), `{"port":3,"name":"bar","host":"x","unknown":1}`)
+	if _, err := r.Reload(context.Background()); err == nil {
+		t.Fatalf("want error; got nothing")
+	}
+	writeFile(t, f.Name(), `{"port":"three","name":"bar"}`)
+	if _, err := r.Reload(context.Background()); err == nil {
+		t.Fatalf("want error; got nothing")
+	}
+	if *port != 2 || *name != "foo" {
+		t.Errorf("unexpected values after failed reload: %d %q", *port, *name)
+	}
+
+	writeFile(t, f.Name(), `{"port":2}`)
+	if _, err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *name != "" {
+		t.Errorf("want removed value to be reset to default; got %q", *name)
+	}
+	exp = append([]Change{
+		{Name: "host", Old: "", New: "cli"},
+		{Name: "port", Old: "0", New: "1"},
+	}, append(exp,
+		Change{Name: "name", Old: "foo", New: ""},
+	)...)
+	if !cmp.Equal(notified, exp) {
+		t.Errorf("unexpected notifications:\n%s", cmp.Diff(exp, notified))
+	}
+}
+
+func TestReloaderList(t *testing.T) {
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	var (
+		list = stringSlice{"default"}
+		port = fs.Int("port", 0, "")
+	)
+	fs.Var(&list, "list", "")
+	Deprecate(fs, "port", "don't use it")
+
+	var warnings []string
+	defer func(prev func(*flag.FlagSet, string, string)) {
+		DeprecationWarning = prev
+	}(DeprecationWarning)
+	DeprecationWarning = func(fs *flag.FlagSet, name, _ string) {
+		if fs == nil {
+			t.Errorf("deprecation warning with nil flag set")
+		}
+		warnings = append(warnings, name)
+	}
+
+	r := Reloader{
+		Flags: fs,
+		Options: []ParseOption{
+			WithParser(&file.Parser{
+				Lookup: file.PathLookup(f.Name()),
+				Syntax: new(json.Syntax),
+			}),
+		},
+	}
+	var reloaded bool
+	r.Subscribe(func([]Change) {
+		if reloaded {
+			return
+		}
+		reloaded = true
+		// Must not deadlock.
+		if _, err := r.Reload(context.Background()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	for _, step := range []struct {
+		content string
+		fail    bool
+		list    []string
+		port    int
+	}{
+		{
+			content: `{"list":["a","b"]}`,
+			list:    []string{"default", "a", "b"},
+		},
+		{
+			content: `{"list":["c"],"port":1}`,
+			list:    []string{"default", "c"},
+			port:    1,
+		},
+		{
+			content: `{"list":["d"],"port":"x"}`,
+			fail:    true,
+			list:    []string{"default", "c"},
+			port:    1,
+		},
+		{
+			content: `{}`,
+			list:    []string{"default"},
+		},
+	} {
+		writeFile(t, f.Name(), step.content)
+		_, err := r.Reload(context.Background())
+		if step.fail && err == nil {
+			t.Fatalf("%s: want error; got nothing", step.content)
+		}
+		if !step.fail && err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.content, err)
+		}
+		if act, exp := []string(list), step.list; !cmp.Equal(act, exp) {
+			t.Errorf("%s: unexpected list:\n%s", step.content, cmp.Diff(exp, act))
+		}
+		if act, exp := *port, step.port; act != exp {
+			t.Errorf("%s: unexpected port: %d; want %d", step.content, act, exp)
+		}
+	}
+	if !reloaded {
+		t.Errorf("subscriber was not called")
+	}
+	if exp := []string{"port"}; !cmp.Equal(warnings, exp) {
+		t.Errorf("unexpected warnings:\n%s", cmp.Diff(exp, warnings))
+	}
+}
+
+func TestReloaderNotCopyable(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Var(valueFunc(func(string) error { return nil }), "func", "")
+	r := Reloader{
+		Flags: fs,
+		Options: []ParseOption{
+			WithParser(setParser("func", "x")),
+		},
+	}
+	_, err := r.Reload(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "can not be copied") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type valueFunc func(string) error
+
+func (fn valueFunc) Set(s string) error { return fn(s) }
+func (fn valueFunc) String() string     { return "" }
+
+func TestReloaderWatch(t *testing.T) {
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	writeFile(t, f.Name(), `{"port":1}`)
+
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	r := Reloader{
+		Flags: fs,
+		Options: []ParseOption{
+			WithParser(&file.Parser{
+				Lookup: file.LookupFlag(flag.NewFlagSet("", 0), "none"),
+				Syntax: new(json.Syntax),
+			}),
+			WithParser(&file.Parser{
+				Lookup: file.PathLookup(f.Name()),
+				Syntax: new(json.Syntax),
+			}),
+		},
+		PollInterval: 5 * time.Millisecond,
+	}
+	if _, err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed := make(chan []Change, 1)
+	r.Subscribe(func(changes []Change) {
+		changed <- changes
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Watch(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	writeFile(t, f.Name(), `{"port":42}`)
+
+	select {
+	case changes := <-changed:
+		exp := []Change{{Name: "port", Old: "1", New: "42"}}
+		if !cmp.Equal(changes, exp) {
+			t.Errorf("unexpected changes:\n%s", cmp.Diff(exp, changes))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no reload happened")
+	}
+}
+
+// writeFile replaces file contents atomically, so polling never observes
+// partially written file.
+func writeFile(t *testing.T, path, content string) {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloaderParse(t *testing.T) {
+	newFlags := func() (*flag.FlagSet, *stringSlice) {
+		fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+		list := new(stringSlice)
+		fs.Var(list, "list", "")
+		return fs, list
+	}
+	opts := []ParseOption{
+		WithParser(setParser("list", "a", "list", "b")),
+	}
+
+	fs, list := newFlags()
+	r := Reloader{
+		Flags:   fs,
+		Options: opts,
+	}
+	if err := r.Parse(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changes, err := r.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if act, exp := []string(*list), []string{"a", "b"}; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected list:\n%s", cmp.Diff(exp, act))
+	}
+	if !isActual(fs, "list") {
+		t.Errorf("want flag to be marked as set")
+	}
+
+	fs, list = newFlags()
+	if err := Parse(context.Background(), fs, opts...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r = Reloader{
+		Flags:   fs,
+		Options: opts,
+	}
+	if _, err := r.Reload(context.Background()); err == nil {
+		t.Fatalf("want error on reload of already parsed flags; got nothing")
+	}
+	if act, exp := []string(*list), []string{"a", "b"}; !cmp.Equal(act, exp) {
+		t.Errorf("unexpected list:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestReloaderMapValue(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	labels := mapValue{"default": "x"}
+	fs.Var(labels, "labels", "")
+
+	var pairs []string
+	r := Reloader{
+		Flags: fs,
+		Options: []ParseOption{
+			WithParser(ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+				for _, p := range pairs {
+					if err := fs.Set("labels", p); err != nil {
+						return err
+					}
+				}
+				return nil
+			})),
+		},
+	}
+	for _, step := range []struct {
+		pairs []string
+		exp   string
+	}{
+		{[]string{"a:1"}, "a:1,default:x"},
+		{[]string{"b:2"}, "b:2,default:x"},
+		{nil, "default:x"},
+	} {
+		pairs = step.pairs
+		if _, err := r.Reload(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if act := labels.String(); act != step.exp {
+			t.Errorf("unexpected labels: %q; want %q", act, step.exp)
+		}
+	}
+}
+
+func TestReloaderCheck(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	list := new(stringSlice)
+	fs.Var(list, "list", "")
+	name := fs.String("name", "", "")
+
+	var args []string
+	r := Reloader{
+		Flags: fs,
+		Options: []ParseOption{
+			WithParser(ParserFunc(func(ctx context.Context, fs parse.FlagSet) error {
+				return setParser(args...).Parse(ctx, fs)
+			})),
+			WithRequired("name"),
+			WithValidator("list", func(v flag.Value) error {
+				if n := len(*v.(*stringSlice)); n > 2 {
+					return fmt.Errorf("too many items: %d", n)
+				}
+				return nil
+			}),
+		},
+	}
+	args = []string{"name", "foo", "list", "a", "list", "b"}
+	if _, err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"name", "bar", "list", "a", "list", "b", "list", "c"}, "too many items: 3"},
+		{[]string{"list", "c"}, "required flags are not specified"},
+	} {
+		args = test.args
+		_, err := r.Reload(context.Background())
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("want error containing %q; got %v", test.err, err)
+		}
+	}
+	if *name != "foo" || !cmp.Equal([]string(*list), []string{"a", "b"}) {
+		t.Errorf("unexpected values after failed reload: %q %q", *name, *list)
+	}
+}
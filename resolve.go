@@ -0,0 +1,152 @@
+package flagutil
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Resolver resolves a value referenced by URI.
+type Resolver interface {
+	Resolve(context.Context, *url.URL) (string, error)
+}
+
+// ResolverFunc is an adapter that allows the use of ordinary functions as
+// Resolver.
+type ResolverFunc func(context.Context, *url.URL) (string, error)
+
+// Resolve implements Resolver interface.
+func (fn ResolverFunc) Resolve(ctx context.Context, u *url.URL) (string, error) {
+	return fn(ctx, u)
+}
+
+// DefaultResolvers holds resolvers available for every Parse() call. Keys are
+// URI schemes.
+//
+// By default it contains resolvers for "file" and "env" schemes:
+//
+//	file:///run/secrets/password  contents of the file without trailing newline;
+//	file://relative/path          the same for the relative path;
+//	env://NAME                    value of the environment variable NAME.
+var DefaultResolvers = map[string]Resolver{
+	"file": ResolverFunc(resolveFile),
+	"env":  ResolverFunc(resolveEnv),
+}
+
+// WithResolver makes Parse() to use r to resolve values of given URI scheme.
+// It overrides resolver of the same scheme from DefaultResolvers.
+//
+// Note that values are resolved only for the flags enabled by WithResolve()
+// or WithResolveFunc() options.
+func WithResolver(scheme string, r Resolver) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		if c.resolvers == nil {
+			c.resolvers = make(map[string]Resolver)
+		}
+		c.resolvers[scheme] = r
+	})
+}
+
+// WithResolve makes Parse() to resolve values of the flags with given names.
+// See WithResolveFunc() for details.
+func WithResolve(names ...string) ParseOptionFunc {
+	return WithResolveFunc(func(f *flag.Flag) bool {
+		for _, name := range names {
+			if f.Name == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithResolveFunc makes Parse() to resolve values of the flags for which
+// check returns true.
+//
+// Value is resolved if it looks like URI with a scheme, e.g.
+// "file:///run/secrets/password". Then resolver registered for that scheme is
+// called and its result is set as the flag value. Values without "://" are set
+// as is. It is an error if there is no resolver for the value scheme.
+//
+// Resolution takes place right before the flag value is set, regardless of
+// the parser which provided the value.
+func WithResolveFunc(check func(*flag.Flag) bool) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		prev := c.resolve
+		c.resolve = func(f *flag.Flag) bool {
+			if prev != nil && prev(f) {
+				return true
+			}
+			return check(f)
+		}
+	})
+}
+
+// resolveFunc returns function resolving values of flags. It returns nil if
+// resolution is not enabled.
+func resolveFunc(ctx context.Context, c *config, flags *flag.FlagSet) func(name, value string) (string, error) {
+	if c.resolve == nil {
+		return nil
+	}
+	return func(name, value string) (string, error) {
+		f := flags.Lookup(name)
+		if f != nil {
+			if a, ok := f.Value.(*aliasValue); ok {
+				f = flags.Lookup(a.target)
+			}
+		}
+		if f == nil || !c.resolve(f) || !strings.Contains(value, "://") {
+			return value, nil
+		}
+		u, err := url.Parse(value)
+		if err != nil {
+			// Parse error contains the value, which might hold credentials.
+			scheme := value[:strings.Index(value, "://")]
+			return "", fmt.Errorf("resolve flag %q: malformed %q URI", name, scheme)
+		}
+		r, has := c.resolvers[u.Scheme]
+		if !has {
+			r, has = DefaultResolvers[u.Scheme]
+		}
+		if !has {
+			return "", fmt.Errorf("no resolver for %q scheme", u.Scheme)
+		}
+		v, err := r.Resolve(ctx, u)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", redact(u), err)
+		}
+		return v, nil
+	}
+}
+
+// redact returns u string representation without user password and query
+// parameters, which might contain credentials.
+func redact(u *url.URL) string {
+	cp := *u
+	if cp.User != nil {
+		cp.User = url.User(cp.User.Username())
+	}
+	cp.RawQuery = ""
+	return cp.String()
+}
+
+func resolveFile(_ context.Context, u *url.URL) (string, error) {
+	bts, err := ioutil.ReadFile(u.Host + u.Path)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(bts), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
+
+func resolveEnv(_ context.Context, u *url.URL) (string, error) {
+	v, has := os.LookupEnv(u.Host)
+	if !has {
+		return "", fmt.Errorf("environment variable is not set")
+	}
+	return v, nil
+}
@@ -0,0 +1,272 @@
+package flagutil
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+// SchemaDraft is the JSON Schema dialect used by ConfigSchema().
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches strings accepted by time.ParseDuration(). Note that
+// both U+00B5 (micro sign) and U+03BC (Greek letter mu) are accepted as the
+// microseconds unit.
+const durationPattern = `^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// ConfigSchema returns JSON Schema describing configuration file accepted by
+// file.Parser for given flags. Flag subsets (see Subset()) are described as
+// nested objects, flags holding slices as arrays and flags holding maps as
+// objects with arbitrary keys. Flag usage messages are used as descriptions.
+//
+// Options are used to find file.Parser among parsers. If there are multiple
+// parsers, keys accepted by each of them are merged. Keys of the parser
+// having Section are nested under the section path. Flags stashed for the
+// parser are not described and objects are closed for undefined properties
+// unless WithIgnoreUndefined() is given for the parser.
+//
+// As well as parse.Setup() does, schema accepts strings in place of the
+// values of other types and single item in place of the list.
+//
+// Note that flag values must implement flag.Getter interface to be described
+// with appropriate type.
+func ConfigSchema(flags *flag.FlagSet, opts ...ParseOption) map[string]interface{} {
+	c := buildConfig(opts)
+	var fps []*parser
+	for _, p := range c.parsers {
+		if fileParser(p.Parser) != nil {
+			fps = append(fps, p)
+		}
+	}
+	if len(fps) == 0 {
+		fps = append(fps, &parser{
+			Parser: new(file.Parser),
+		})
+	}
+	b := schemaBuilder{
+		root:   schemaObject(),
+		objs:   make(map[string]map[string]interface{}),
+		closed: make(map[string]bool),
+	}
+	for _, p := range fps {
+		var section []string
+		if s := fileParser(p.Parser).Section; s != "" {
+			section = strings.Split(s, parse.SetSeparator)
+		}
+		flags.VisitAll(func(f *flag.Flag) {
+			if p.stash != nil && p.stash(f) {
+				return
+			}
+			b.add(section, strings.Split(f.Name, SetSeparator), flagSchema(f), p.ignoreUndefined)
+		})
+		// Section object is described even if there are no flags.
+		b.own(b.object(section), section, p.ignoreUndefined)
+	}
+	for path, closed := range b.closed {
+		if closed {
+			b.objs[path]["additionalProperties"] = false
+		}
+	}
+	root := b.root
+	root["$schema"] = SchemaDraft
+	if name := flags.Name(); name != "" {
+		root["title"] = name
+	}
+	return root
+}
+
+// schemaBuilder builds schema of the configuration merged from the keys
+// accepted by multiple parsers.
+type schemaBuilder struct {
+	root map[string]interface{}
+
+	// objs holds objects owned by some parser by their paths.
+	objs map[string]map[string]interface{}
+
+	// closed holds true for paths of objects closed for undefined properties.
+	// Object is closed only if none of the parsers owning it ignores
+	// undefined keys. Objects not owned by any parser (e.g. objects on the
+	// section path) are left open.
+	closed map[string]bool
+}
+
+// add adds schema s of the flag with given path nested under the section.
+func (b *schemaBuilder) add(section, path []string, s map[string]interface{}, open bool) {
+	obj := b.object(section)
+	b.own(obj, section, open)
+	for i, key := range path[:len(path)-1] {
+		obj = schemaChild(obj, key)
+		b.own(obj, append(section[:len(section):len(section)], path[:i+1]...), open)
+	}
+	props := obj["properties"].(map[string]interface{})
+	key := path[len(path)-1]
+	if prev, has := props[key]; has {
+		// Nested flags might be already there. Both flag and nested flags are
+		// accepted by parse.Setup(). Otherwise the same flag is described
+		// for the other parser.
+		x := prev.(map[string]interface{})
+		if alt, ok := x["anyOf"].([]interface{}); ok {
+			x = alt[1].(map[string]interface{})
+		}
+		if isSchemaObject(x) {
+			s = map[string]interface{}{
+				"anyOf": []interface{}{s, x},
+			}
+		}
+	}
+	props[key] = s
+}
+
+// object returns object at given path. It creates intermediate objects if
+// needed.
+func (b *schemaBuilder) object(path []string) map[string]interface{} {
+	obj := b.root
+	for _, key := range path {
+		obj = schemaChild(obj, key)
+	}
+	return obj
+}
+
+// own marks object obj at given path as owned by the parser.
+func (b *schemaBuilder) own(obj map[string]interface{}, path []string, open bool) {
+	key := strings.Join(path, "\x00")
+	b.objs[key] = obj
+	if open {
+		b.closed[key] = false
+	} else if _, has := b.closed[key]; !has {
+		b.closed[key] = true
+	}
+}
+
+// WriteConfigSchema writes JSON encoded ConfigSchema() result into w.
+func WriteConfigSchema(w io.Writer, flags *flag.FlagSet, opts ...ParseOption) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ConfigSchema(flags, opts...))
+}
+
+func schemaObject() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": make(map[string]interface{}),
+	}
+}
+
+func isSchemaObject(x interface{}) bool {
+	s, ok := x.(map[string]interface{})
+	return ok && s["type"] == "object" && s["properties"] != nil
+}
+
+// schemaChild returns object schema of the property key of obj. It creates
+// one if there is no such property yet.
+func schemaChild(obj map[string]interface{}, key string) map[string]interface{} {
+	props := obj["properties"].(map[string]interface{})
+	prev, has := props[key]
+	if !has {
+		child := schemaObject()
+		props[key] = child
+		return child
+	}
+	s := prev.(map[string]interface{})
+	if isSchemaObject(s) {
+		return s
+	}
+	// Property is a flag; describe nested flags as an alternative.
+	if alt, ok := s["anyOf"].([]interface{}); ok {
+		return alt[1].(map[string]interface{})
+	}
+	child := schemaObject()
+	props[key] = map[string]interface{}{
+		"anyOf": []interface{}{s, child},
+	}
+	return child
+}
+
+func flagSchema(f *flag.Flag) map[string]interface{} {
+	s := valueSchema(f)
+	if _, usage := unquoteUsage(UnquoteQuoted, f); usage != "" {
+		s["description"] = usage
+	}
+	if _, _, deprecated := deprecation(f); deprecated {
+		s["deprecated"] = true
+	}
+	return s
+}
+
+// valueSchema returns schema of the flag value. Its type is the one inferred
+// for usage message and flags inventory (see inferType()).
+func valueSchema(f *flag.Flag) map[string]interface{} {
+	typ := inferType(f)
+	if typ != "list" && typ != "object" {
+		return typeSchema(typ)
+	}
+	t := valueType(f.Value)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	elem := typeSchema(typeName(t.Elem()))
+	if typ == "list" {
+		// Single item is set as is by parse.Setup().
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				elem,
+				map[string]interface{}{
+					"type":  "array",
+					"items": elem,
+				},
+			},
+		}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": elem,
+	}
+}
+
+// typeSchema returns schema of the scalar value of type with given name (see
+// typeName()). Values of non-string types might be given as strings as well,
+// since parse.Setup() sets them as strings anyway. It returns schema
+// accepting any scalar if type is not known.
+func typeSchema(name string) map[string]interface{} {
+	switch name {
+	case "bool":
+		return map[string]interface{}{
+			"type": []interface{}{"boolean", "string"},
+		}
+	case "duration":
+		return map[string]interface{}{
+			"type":    "string",
+			"pattern": durationPattern,
+		}
+	case "string":
+		return map[string]interface{}{"type": "string"}
+	case "float":
+		return map[string]interface{}{
+			"type": []interface{}{"number", "string"},
+		}
+	case "int":
+		return map[string]interface{}{
+			"type": []interface{}{"integer", "string"},
+		}
+	case "uint":
+		return map[string]interface{}{
+			"type":    []interface{}{"integer", "string"},
+			"minimum": 0,
+		}
+	}
+	return map[string]interface{}{
+		"type": []interface{}{"string", "number", "boolean"},
+	}
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func isTextType(t reflect.Type) bool {
+	return t.Implements(textMarshalerType)
+}
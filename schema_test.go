@@ -0,0 +1,205 @@
+package flagutil
+
+import (
+	"bytes"
+	"flag"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func TestWriteConfigSchema(t *testing.T) {
+	var cfg bindConfig
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	if err := Bind(fs, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	Alias(fs, "listen-port", "port")
+
+	var buf bytes.Buffer
+	err := WriteConfigSchema(&buf, fs,
+		WithParser(&file.Parser{},
+			WithStashPrefix("e"),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "additionalProperties": false,
+  "properties": {
+    "database": {
+      "additionalProperties": false,
+      "properties": {
+        "endpoint": {
+          "description": "database endpoint",
+          "type": "string"
+        },
+        "max-conns": {
+          "minimum": 0,
+          "type": [
+            "integer",
+            "string"
+          ]
+        }
+      },
+      "type": "object"
+    },
+    "debug": {
+      "description": "enable debug mode",
+      "type": [
+        "boolean",
+        "string"
+      ]
+    },
+    "ip": {
+      "type": "string"
+    },
+    "labels": {
+      "additionalProperties": {
+        "type": [
+          "integer",
+          "string"
+        ]
+      },
+      "description": "labels",
+      "type": "object"
+    },
+    "listen-port": {
+      "deprecated": true,
+      "type": [
+        "integer",
+        "string"
+      ]
+    },
+    "port": {
+      "description": "port to bind to",
+      "type": [
+        "integer",
+        "string"
+      ]
+    },
+    "ratio": {
+      "type": [
+        "number",
+        "string"
+      ]
+    },
+    "tags": {
+      "oneOf": [
+        {
+          "type": "string"
+        },
+        {
+          "items": {
+            "type": "string"
+          },
+          "type": "array"
+        }
+      ]
+    },
+    "timeout": {
+      "description": "request timeout",
+      "pattern": "^[-+]?(0|(([0-9]+(\\.[0-9]*)?|\\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$",
+      "type": "string"
+    }
+  },
+  "title": "app",
+  "type": "object"
+}
+`
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected schema:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestConfigSchemaIgnoreUndefined(t *testing.T) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.String("a", "", "")
+	fs.String("a.b", "", "")
+
+	s := ConfigSchema(fs, WithParser(&file.Parser{}, WithIgnoreUndefined()))
+	if _, has := s["additionalProperties"]; has {
+		t.Errorf("unexpected additionalProperties in the root object")
+	}
+	a := s["properties"].(map[string]interface{})["a"].(map[string]interface{})
+	alt, ok := a["anyOf"].([]interface{})
+	if !ok || len(alt) != 2 {
+		t.Fatalf("want anyOf for property being both flag and subset; got %v", a)
+	}
+	if act, exp := alt[0].(map[string]interface{})["type"], "string"; act != exp {
+		t.Errorf("unexpected flag type: %v; want %v", act, exp)
+	}
+	if act, exp := alt[1].(map[string]interface{})["type"], "object"; act != exp {
+		t.Errorf("unexpected subset type: %v; want %v", act, exp)
+	}
+}
+
+func TestConfigSchemaSections(t *testing.T) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.String("db.host", "", "")
+
+	s := ConfigSchema(fs,
+		WithParser(&file.Parser{Section: "server.app"}),
+		WithParser(&file.Parser{}, WithIgnoreUndefined(), WithStashName("port")),
+	)
+	lookup := func(path ...string) map[string]interface{} {
+		obj := s
+		for _, key := range path {
+			props := obj["properties"].(map[string]interface{})
+			x, ok := props[key].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			obj = x
+		}
+		return obj
+	}
+	for _, test := range []struct {
+		path   []string
+		exists bool
+		closed bool
+	}{
+		{path: nil, exists: true},
+		{path: []string{"server"}, exists: true},
+		{path: []string{"server", "app"}, exists: true, closed: true},
+		{path: []string{"server", "app", "port"}, exists: true},
+		{path: []string{"server", "app", "db"}, exists: true, closed: true},
+		{path: []string{"server", "app", "db", "host"}, exists: true},
+		{path: []string{"db"}, exists: true},
+		{path: []string{"db", "host"}, exists: true},
+		{path: []string{"port"}},
+	} {
+		obj := lookup(test.path...)
+		if act, exp := obj != nil, test.exists; act != exp {
+			t.Errorf("property %v exists: %t; want %t", test.path, act, exp)
+			continue
+		}
+		if obj == nil {
+			continue
+		}
+		_, closed := obj["additionalProperties"]
+		if act, exp := closed, test.closed; act != exp {
+			t.Errorf("property %v closed: %t; want %t", test.path, act, exp)
+		}
+	}
+}
+
+func TestDurationPattern(t *testing.T) {
+	re := regexp.MustCompile(durationPattern)
+	for _, s := range []string{
+		"0", "5s", "-1.5h", "+.5m", "5.s", "1h30m", "10\u00b5s", "10\u03bcs",
+		"", ".s", ".", "5", "5.", "s", "1h.m", "--1s",
+	} {
+		_, err := time.ParseDuration(s)
+		if act, exp := re.MatchString(s), err == nil; act != exp {
+			t.Errorf("pattern matches %q: %t; want %t", s, act, exp)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package flagutil
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"sync"
+)
+
+// subset describes flag subset registered by Subset().
+type subset struct {
+	prefix      string
+	description string
+	parent      *subset
+}
+
+// subsets holds subsets of the flags registered by Subset(). Subsets are
+// keyed by flag set and by name of the flag within it, so flag values are
+// left untouched.
+var subsets = struct {
+	sync.RWMutex
+	m map[*flag.FlagSet]map[string]*subset
+}{
+	m: make(map[*flag.FlagSet]map[string]*subset),
+}
+
+// setSubset makes flag with given name a member of the subset s within fs.
+func setSubset(fs *flag.FlagSet, name string, s *subset) {
+	subsets.Lock()
+	defer subsets.Unlock()
+	m := subsets.m[fs]
+	if m == nil {
+		m = make(map[string]*subset)
+		subsets.m[fs] = m
+	}
+	m[name] = s
+}
+
+// lookupSubset returns the innermost subset of the flag with given name
+// within fs. It returns nil if flag is not a member of any subset.
+func lookupSubset(fs *flag.FlagSet, name string) *subset {
+	subsets.RLock()
+	defer subsets.RUnlock()
+	return subsets.m[fs][name]
+}
+
+// inheritSubset makes flag with given name within dst a member of the same
+// subset as the flag with the same name within src.
+func inheritSubset(dst, src *flag.FlagSet, name string) {
+	if s := lookupSubset(src, name); s != nil {
+		setSubset(dst, name, s)
+	}
+}
+
+// forgetSubsets drops subsets registered within fs.
+func forgetSubsets(fs *flag.FlagSet) {
+	subsets.Lock()
+	defer subsets.Unlock()
+	delete(subsets.m, fs)
+}
+
+func nest(inner, outer *subset, nested map[*subset]*subset) *subset {
+	if inner == nil {
+		return outer
+	}
+	if s, has := nested[inner]; has {
+		return s
+	}
+	s := &subset{
+		prefix:      outer.prefix + "." + inner.prefix,
+		description: inner.description,
+		parent:      nest(inner.parent, outer, nested),
+	}
+	nested[inner] = s
+	return s
+}
+
+// flagSubset returns prefix of the innermost subset flag f belongs to within
+// fs. It returns empty string if flag is not a member of any subset.
+func flagSubset(fs *flag.FlagSet, f *flag.Flag) string {
+	if s := lookupSubset(fs, f.Name); s != nil {
+		return s.prefix
+	}
+	return ""
+}
+
+// inSubset returns true if flag f is a member of the subset with given prefix
+// or of some of its nested subsets within fs.
+func inSubset(fs *flag.FlagSet, f *flag.Flag, prefix string) bool {
+	for s := lookupSubset(fs, f.Name); s != nil; s = s.parent {
+		if s.prefix == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSubset returns true if fs has flags of the subset with given prefix.
+func hasSubset(fs *flag.FlagSet, prefix string) (has bool) {
+	fs.VisitAll(func(f *flag.Flag) {
+		has = has || inSubset(fs, f, prefix)
+	})
+	return has
+}
+
+// section represents a group of flags sharing the same prefix.
+type section struct {
+	prefix      string
+	description string
+	flags       []*flag.Flag
+	sections    []*section
+}
+
+// buildSections groups given flags of fs into the tree of sections by the
+// subsets they belong to.
+func buildSections(fs *flag.FlagSet, flags []*flag.Flag) *section {
+	root := new(section)
+	for _, f := range flags {
+		var path []*subset
+		for s := lookupSubset(fs, f.Name); s != nil; s = s.parent {
+			path = append(path, s)
+		}
+		sec := root
+		for i := len(path) - 1; i >= 0; i-- {
+			sec = sec.child(path[i])
+		}
+		sec.flags = append(sec.flags, f)
+	}
+	return root
+}
+
+func (s *section) child(sub *subset) *section {
+	for _, c := range s.sections {
+		if c.prefix == sub.prefix {
+			if c.description == "" {
+				c.description = sub.description
+			}
+			return c
+		}
+	}
+	c := &section{
+		prefix:      sub.prefix,
+		description: sub.description,
+	}
+	s.sections = append(s.sections, c)
+	return c
+}
+
+// lookup returns section with given prefix. It returns nil if there is no
+// such section.
+func (s *section) lookup(prefix string) *section {
+	if s.prefix == prefix {
+		return s
+	}
+	for _, c := range s.sections {
+		if c.prefix == prefix || strings.HasPrefix(prefix, c.prefix+SetSeparator) {
+			return c.lookup(prefix)
+		}
+	}
+	return nil
+}
+
+// titled returns true if section must be printed with a heading. That is,
+// intermediate sections without own flags and description are not printed.
+func (s *section) titled() bool {
+	return s.prefix != "" && (len(s.flags) > 0 || s.description != "")
+}
+
+func printSection(c *config, flags *flag.FlagSet, names func(*flag.Flag) []string, s *section, indent string) {
+	if s.titled() {
+		w := flags.Output()
+		io.WriteString(w, indent+s.prefix+":\n")
+		if s.description != "" {
+			io.WriteString(w, indent+"  ")
+			io.WriteString(w, strings.ReplaceAll(s.description, "\n", "\n"+indent+"  "))
+			io.WriteString(w, "\n\n")
+		}
+	}
+	for _, f := range s.flags {
+		printFlag(c, flags, names, f, indent)
+	}
+	if s.titled() {
+		indent += "  "
+	}
+	for _, sub := range s.sections {
+		printSection(c, flags, names, sub, indent)
+	}
+}
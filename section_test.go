@@ -0,0 +1,161 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+)
+
+func sectionFlags(t *testing.T, buf *bytes.Buffer) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(buf)
+	fs.Bool("verbose", false, "verbose output")
+	err := Subset(fs, "database", func(sub *flag.FlagSet) {
+		sub.String("host", "localhost", "database host")
+		Subset(sub, "pool", func(sub *flag.FlagSet) {
+			sub.Int("size", 10, "pool size")
+		}, WithSubsetDescription("Connection pool options."))
+	}, WithSubsetDescription("Database connection options."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not a subset member.
+	fs.String("db.name", "", "database name")
+	Subset(fs, "http", func(sub *flag.FlagSet) {
+		sub.Int("port", 80, "port to bind to")
+	})
+	return fs
+}
+
+var dashPrinter = &fullParser{
+	Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+		return func(f *flag.Flag, it func(string)) {
+			it("--" + f.Name)
+		}, nil
+	}),
+}
+
+func TestPrintDefaultsSections(t *testing.T) {
+	var buf bytes.Buffer
+	fs := sectionFlags(t, &buf)
+	err := PrintDefaults(context.Background(), fs,
+		WithParser(dashPrinter),
+		WithSections(),
+		WithUnquoteUsageMode(UnquoteNothing),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "" +
+		"  --db.name\n" +
+		"    \tdatabase name (default \"\")\n" +
+		"\n" +
+		"  --verbose\n" +
+		"    \tverbose output (default false)\n" +
+		"\n" +
+		"database:\n" +
+		"  Database connection options.\n" +
+		"\n" +
+		"  --database.host\n" +
+		"    \tdatabase host (default \"localhost\")\n" +
+		"\n" +
+		"  database.pool:\n" +
+		"    Connection pool options.\n" +
+		"\n" +
+		"    --database.pool.size\n" +
+		"      \tpool size (default 10)\n" +
+		"\n" +
+		"http:\n" +
+		"  --http.port\n" +
+		"    \tport to bind to (default 80)\n" +
+		"\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected usage:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestParseHelpSubset(t *testing.T) {
+	var buf bytes.Buffer
+	fs := sectionFlags(t, &buf)
+	err := Parse(context.Background(), fs,
+		WithParser(ParserFunc(func(context.Context, parse.FlagSet) error {
+			return parse.Help("help", "database.pool")
+		})),
+		WithParser(dashPrinter),
+		WithCustomUsage(),
+		WithUnquoteUsageMode(UnquoteNothing),
+	)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("want help error; got %v", err)
+	}
+	exp := "" +
+		"Usage of test:\n" +
+		"database.pool:\n" +
+		"  Connection pool options.\n" +
+		"\n" +
+		"  --database.pool.size\n" +
+		"    \tpool size (default 10)\n" +
+		"\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected usage:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestParseHelpUnknownSubset(t *testing.T) {
+	for _, subset := range []string{"db", "false"} {
+		t.Run(subset, func(t *testing.T) {
+			var buf bytes.Buffer
+			fs := sectionFlags(t, &buf)
+			err := Parse(context.Background(), fs,
+				WithParser(ParserFunc(func(context.Context, parse.FlagSet) error {
+					return parse.Help("help", subset)
+				})),
+				WithParser(dashPrinter),
+				WithCustomUsage(),
+			)
+			if err == nil || errors.Is(err, flag.ErrHelp) {
+				t.Fatalf("want non-help error; got %v", err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("unexpected usage:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestSubsetValue(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	labels := make(mapValue)
+	err := Subset(fs, "app", func(sub *flag.FlagSet) {
+		sub.Var(labels, "labels", "")
+		sub.Bool("debug", false, "")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fs.Lookup("app.labels").Value.(mapValue); !ok {
+		t.Errorf("unexpected subset flag value type: %T", fs.Lookup("app.labels").Value)
+	}
+	debug := fs.Lookup("app.debug")
+	if !isBoolFlag(debug) {
+		t.Errorf("want subset flag to be boolean")
+	}
+	if _, ok := debug.Value.(flag.Getter); !ok {
+		t.Errorf("want subset flag value to implement flag.Getter")
+	}
+	if act, exp := flagSubset(fs, debug), "app"; act != exp {
+		t.Errorf("unexpected subset: %q; want %q", act, exp)
+	}
+
+	cp := flag.NewFlagSet("copy", flag.ContinueOnError)
+	Copy(cp, fs)
+	if act, exp := flagSubset(cp, cp.Lookup("app.debug")), "app"; act != exp {
+		t.Errorf("unexpected subset of copied flag: %q; want %q", act, exp)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package flagutil
+
+// terminalWidth returns width of the terminal referred by fd. Terminal size
+// is not detected on this platform, so it always returns zero.
+func terminalWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package flagutil
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth returns width of the terminal referred by fd. It returns zero
+// if fd doesn't refer to a terminal.
+func terminalWidth(fd uintptr) int {
+	var ws struct {
+		row, col       uint16
+		xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL, fd,
+		uintptr(syscall.TIOCGWINSZ),
+		uintptr(unsafe.Pointer(&ws)),
+	)
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.col)
+}
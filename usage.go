@@ -0,0 +1,277 @@
+package flagutil
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// FlagUsage holds usage information of a single flag. It is a data passed to
+// UsageRenderer.
+type FlagUsage struct {
+	// Flag is the flag being described.
+	Flag *flag.Flag
+
+	// Names holds names given to the flag by all parsers implementing Printer
+	// interface, e.g. "--port", "$APP_PORT".
+	Names []string
+
+	// Type is a name of the flag value type. It depends on UnquoteUsageMode
+	// (see WithUnquoteUsageMode()).
+	Type string
+
+	// Usage is a flag usage message without the type name.
+	Usage string
+
+	// Default is a default value of the flag as printed within usage message
+	// (e.g. strings are quoted). It is empty if flag has no default value.
+	Default string
+
+	// Required is true if flag is required (see WithRequired()).
+	Required bool
+
+	// Deprecation is a note about flag deprecation (see Alias() and
+	// Deprecate()). It is empty if flag is not deprecated.
+	Deprecation string
+
+	// Hidden is true if flag is hidden (see WithHiddenFunc()). Hidden flags
+	// are passed to renderer only if they must be shown.
+	Hidden bool
+
+	// Subset is a prefix of the subset flag belongs to (see Subset()). It is
+	// empty for top-level flags.
+	Subset string
+}
+
+// Note returns a note about flag requirement, deprecation or its default
+// value. It returns empty string if there is nothing to note.
+func (u FlagUsage) Note() string {
+	switch {
+	case u.Deprecation != "":
+		return u.Deprecation
+	case u.Required:
+		return "required"
+	case u.Default != "":
+		return "default " + u.Default
+	}
+	return ""
+}
+
+// Description returns flag usage message followed by the note in parentheses
+// (see Note()).
+func (u FlagUsage) Description() string {
+	note := u.Note()
+	if note == "" {
+		return u.Usage
+	}
+	if u.Usage == "" {
+		return note
+	}
+	return u.Usage + " (" + note + ")"
+}
+
+// UsageRenderer renders usage message of the flags.
+type UsageRenderer interface {
+	RenderUsage(io.Writer, []FlagUsage) error
+}
+
+// UsageRendererFunc is an adapter that allows the use of ordinary functions
+// as UsageRenderer.
+type UsageRendererFunc func(io.Writer, []FlagUsage) error
+
+// RenderUsage implements UsageRenderer interface.
+func (fn UsageRendererFunc) RenderUsage(w io.Writer, flags []FlagUsage) error {
+	return fn(w, flags)
+}
+
+// UsageTemplate returns UsageRenderer which executes t with []FlagUsage as
+// data.
+func UsageTemplate(t *template.Template) UsageRenderer {
+	return UsageRendererFunc(func(w io.Writer, flags []FlagUsage) error {
+		return t.Execute(w, flags)
+	})
+}
+
+// ColumnUsage renders usage message in GNU-like two-column layout: flag names
+// and type in the left column and description in the right column, wrapped to
+// the given width.
+//
+// Hidden flags are listed after the regular ones under the "Advanced"
+// heading.
+type ColumnUsage struct {
+	// Width is a maximum width of the usage lines. If Width is zero, width
+	// of the terminal the usage is written to is used. If output is not a
+	// terminal (or its size can't be detected on the platform), value of
+	// $COLUMNS environment variable is used, or 80 if it is not set.
+	Width int
+
+	// MaxColumn is a maximum width of the left column. Descriptions of the
+	// flags with longer left column start on the next line. If MaxColumn is
+	// zero, 30 is used.
+	MaxColumn int
+}
+
+// getTerminalWidth returns width of the terminal referred by fd. It is a
+// variable to be replaced in tests.
+var getTerminalWidth = terminalWidth
+
+// outputWidth returns width of the output w. That is, width of the terminal
+// if w is a terminal, value of $COLUMNS or 80 otherwise.
+func outputWidth(w io.Writer) (width int) {
+	if f, ok := w.(interface{ Fd() uintptr }); ok {
+		width = getTerminalWidth(f.Fd())
+	}
+	if width <= 0 {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	if width <= 0 {
+		width = 80
+	}
+	return width
+}
+
+// RenderUsage implements UsageRenderer interface.
+func (c ColumnUsage) RenderUsage(w io.Writer, flags []FlagUsage) error {
+	width := c.Width
+	if width <= 0 {
+		width = outputWidth(w)
+	}
+	max := c.MaxColumn
+	if max <= 0 {
+		max = 30
+	}
+
+	left := make([]string, len(flags))
+	var column int
+	for i, f := range flags {
+		left[i] = "  " + strings.Join(f.Names, ", ")
+		if f.Type != "" {
+			left[i] += " " + f.Type
+		}
+		if n := utf8.RuneCountInString(left[i]); n <= max && n > column {
+			column = n
+		}
+	}
+	column += 2 // Gap between columns.
+
+	text := width - column
+	if text < 20 {
+		text = 20
+	}
+	var sb strings.Builder
+	render := func(hidden bool) {
+		for i, f := range flags {
+			if f.Hidden != hidden {
+				continue
+			}
+			sb.WriteString(left[i])
+			pad := column - utf8.RuneCountInString(left[i])
+			lines := wrap(f.Description(), text)
+			if pad < 2 && len(lines) > 0 {
+				sb.WriteByte('\n')
+				pad = column
+			}
+			for j, line := range lines {
+				if j > 0 {
+					pad = column
+				}
+				sb.WriteString(strings.Repeat(" ", pad))
+				sb.WriteString(line)
+				sb.WriteByte('\n')
+			}
+			if len(lines) == 0 {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	render(false)
+	for _, f := range flags {
+		if f.Hidden {
+			sb.WriteString("\nAdvanced:\n")
+			render(true)
+			break
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// wrap splits s into lines not wider than width. Lines are broken on spaces;
+// words longer than width are not broken. Explicit line breaks are preserved.
+func wrap(s string, width int) (lines []string) {
+	if s == "" {
+		return nil
+	}
+	for _, p := range strings.Split(s, "\n") {
+		var (
+			line string
+			n    int
+		)
+		for _, word := range strings.Fields(p) {
+			m := utf8.RuneCountInString(word)
+			if n > 0 && n+1+m > width {
+				lines = append(lines, line)
+				line, n = "", 0
+			}
+			if n > 0 {
+				line += " "
+				n++
+			}
+			line += word
+			n += m
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// WithUsageRenderer makes usage message to be rendered by r instead of the
+// default layout. Note that WithSections() option has no effect when custom
+// renderer is used; renderer may group flags by FlagUsage.Subset field
+// instead.
+func WithUsageRenderer(r UsageRenderer) ParseOptionFunc {
+	return ParseOptionFunc(func(c *config) {
+		c.renderer = r
+	})
+}
+
+// FlagsUsage returns usage information of the flags which would be listed
+// within the usage message (see PrintDefaults()).
+func FlagsUsage(ctx context.Context, flags *flag.FlagSet, opts ...ParseOption) ([]FlagUsage, error) {
+	c := buildConfig(opts)
+	names, err := flagNames(ctx, &c, flags)
+	if err != nil {
+		return nil, err
+	}
+	return flagsUsage(&c, flags, names, usageFlags(&c, flags, names)), nil
+}
+
+func flagsUsage(c *config, flags *flag.FlagSet, names func(*flag.Flag) []string, list []*flag.Flag) []FlagUsage {
+	ret := make([]FlagUsage, len(list))
+	for i, f := range list {
+		ret[i] = flagUsage(c, flags, names, f)
+	}
+	return ret
+}
+
+// flagUsage returns usage information of the flag f. It is the only source
+// of flag notes for every usage format: default usage message, renderers and
+// documentation.
+func flagUsage(c *config, flags *flag.FlagSet, names func(*flag.Flag) []string, f *flag.Flag) FlagUsage {
+	u := FlagUsage{
+		Flag:     f,
+		Names:    names(f),
+		Default:  defValue(f),
+		Required: c.isRequired(f),
+		Hidden:   c.isHidden(f),
+		Subset:   flagSubset(flags, f),
+	}
+	u.Type, u.Usage = unquoteUsage(c.unquoteUsageMode, f)
+	u.Deprecation, _ = deprecationNote(flags, names, f)
+	return u
+}
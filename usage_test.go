@@ -0,0 +1,227 @@
+package flagutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/gobwas/flagutil/parse"
+)
+
+func usageFlagSet(buf *bytes.Buffer) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(buf)
+	fs.Int("port", 4050, "port to bind to")
+	Subset(fs, "database", func(sub *flag.FlagSet) {
+		sub.String("endpoint", "", "database `address` to connect to; it must be reachable from the host")
+	})
+	fs.Bool("tune-gc", false, "tune garbage collector")
+	fs.String("token", "", "access token")
+	return fs
+}
+
+var envReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+var envPrinter = &fullParser{
+	Printer: PrinterFunc(func(_ context.Context, fs parse.FlagSet) (func(*flag.Flag, func(string)), error) {
+		return func(f *flag.Flag, it func(string)) {
+			it("--" + f.Name)
+			it("$" + strings.ToUpper(envReplacer.Replace(f.Name)))
+		}, nil
+	}),
+}
+
+func TestFlagsUsage(t *testing.T) {
+	var buf bytes.Buffer
+	fs := usageFlagSet(&buf)
+	Deprecate(fs, "tune-gc", "")
+	act, err := FlagsUsage(context.Background(), fs,
+		WithParser(envPrinter),
+		WithRequired("token"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range act {
+		act[i].Flag = nil
+	}
+	exp := []FlagUsage{
+		{
+			Names:   []string{"--database.endpoint", "$DATABASE_ENDPOINT"},
+			Type:    "address",
+			Usage:   "database address to connect to; it must be reachable from the host",
+			Default: `""`,
+			Subset:  "database",
+		},
+		{
+			Names:   []string{"--port", "$PORT"},
+			Type:    "int",
+			Usage:   "port to bind to",
+			Default: "4050",
+		},
+		{
+			Names:    []string{"--token", "$TOKEN"},
+			Type:     "string",
+			Usage:    "access token",
+			Default:  `""`,
+			Required: true,
+		},
+		{
+			Names:       []string{"--tune-gc", "$TUNE_GC"},
+			Type:        "bool",
+			Usage:       "tune garbage collector",
+			Default:     "false",
+			Deprecation: "deprecated",
+		},
+	}
+	if !cmp.Equal(act, exp) {
+		t.Errorf("unexpected flags usage:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+func TestColumnUsage(t *testing.T) {
+	var buf bytes.Buffer
+	fs := usageFlagSet(&buf)
+	err := PrintDefaults(context.Background(), fs,
+		WithParser(envPrinter),
+		WithHidden("tune-gc"),
+		WithShowHidden(),
+		WithUsageRenderer(ColumnUsage{
+			Width:     60,
+			MaxColumn: 20,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "" +
+		"  --database.endpoint, $DATABASE_ENDPOINT address\n" +
+		"                     database address to connect to; it must\n" +
+		"                     be reachable from the host (default \"\")\n" +
+		"  --port, $PORT int  port to bind to (default 4050)\n" +
+		"  --token, $TOKEN string\n" +
+		"                     access token (default \"\")\n" +
+		"\n" +
+		"Advanced:\n" +
+		"  --tune-gc, $TUNE_GC bool\n" +
+		"                     tune garbage collector (default false)\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected usage:\n%s", cmp.Diff(exp, act))
+	}
+}
+
+// fdWriter is a writer referring to the file descriptor.
+type fdWriter struct {
+	bytes.Buffer
+	fd uintptr
+}
+
+func (w *fdWriter) Fd() uintptr {
+	return w.fd
+}
+
+func TestOutputWidth(t *testing.T) {
+	defer func(prev func(uintptr) int) {
+		getTerminalWidth = prev
+	}(getTerminalWidth)
+	getTerminalWidth = func(fd uintptr) int {
+		if fd == 1 {
+			return 120
+		}
+		return 0
+	}
+	defer func(prev string, has bool) {
+		if has {
+			os.Setenv("COLUMNS", prev)
+		} else {
+			os.Unsetenv("COLUMNS")
+		}
+	}(os.LookupEnv("COLUMNS"))
+
+	for _, test := range []struct {
+		name    string
+		w       io.Writer
+		columns string
+		exp     int
+	}{
+		{
+			name:    "terminal",
+			w:       &fdWriter{fd: 1},
+			columns: "100",
+			exp:     120,
+		},
+		{
+			name:    "not a terminal",
+			w:       &fdWriter{fd: 2},
+			columns: "100",
+			exp:     100,
+		},
+		{
+			name:    "not a file",
+			w:       new(bytes.Buffer),
+			columns: "100",
+			exp:     100,
+		},
+		{
+			name: "default",
+			w:    &fdWriter{fd: 2},
+			exp:  80,
+		},
+		{
+			name:    "bad columns",
+			w:       new(bytes.Buffer),
+			columns: "wide",
+			exp:     80,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv("COLUMNS", test.columns)
+			if act, exp := outputWidth(test.w), test.exp; act != exp {
+				t.Errorf("unexpected width: %d; want %d", act, exp)
+			}
+		})
+	}
+}
+
+func TestTerminalWidthNotTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if n := terminalWidth(w.Fd()); n != 0 {
+		t.Errorf("unexpected width of the pipe: %d", n)
+	}
+}
+
+func TestUsageTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	fs := usageFlagSet(&buf)
+	tmpl := template.Must(template.New("usage").Parse("" +
+		"{{range .}}{{index .Names 0}}: {{.Description}}\n{{end}}",
+	))
+	err := PrintDefaults(context.Background(), fs,
+		WithParser(envPrinter),
+		WithHidden("tune-gc"),
+		WithRequired("token"),
+		WithUsageRenderer(UsageTemplate(tmpl)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "" +
+		"--database.endpoint: database address to connect to; it must be reachable from the host (default \"\")\n" +
+		"--port: port to bind to (default 4050)\n" +
+		"--token: access token (required)\n"
+	if act := buf.String(); act != exp {
+		t.Errorf("unexpected usage:\n%s", cmp.Diff(exp, act))
+	}
+}
@@ -0,0 +1,196 @@
+package flagutil
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes flag value which did not pass validation.
+type ValidationError struct {
+	// Name is a name of the flag.
+	Name string
+
+	// Value is a string representation of the flag value.
+	Value string
+
+	// Source is a source of the flag value. It has nil Parser if flag value
+	// was not provided by any parser.
+	Source Source
+
+	// Err is an error returned by validator.
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	from := "default"
+	if e.Source.Parser != nil {
+		from = "from " + e.Source.String()
+	}
+	return fmt.Sprintf(
+		"invalid value %q of flag %q (%s): %v",
+		e.Value, e.Name, from, e.Err,
+	)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors holds all validation errors occurred during Parse().
+type ValidationErrors []*ValidationError
+
+func (es ValidationErrors) Error() string {
+	if len(es) == 1 {
+		return es[0].Error()
+	}
+	var sb strings.Builder
+	sb.WriteString("validation failed:")
+	for _, e := range es {
+		sb.WriteString("\n  ")
+		sb.WriteString(e.Error())
+	}
+	return sb.String()
+}
+
+func validate(c *config, flags *flag.FlagSet, sources Sources) error {
+	if len(c.validators) == 0 {
+		return nil
+	}
+	var errs ValidationErrors
+	flags.VisitAll(func(f *flag.Flag) {
+		for _, v := range c.validators[f.Name] {
+			err := v(f.Value)
+			if err == nil {
+				continue
+			}
+			errs = append(errs, &ValidationError{
+				Name:   f.Name,
+				Value:  f.Value.String(),
+				Source: sources[f.Name],
+				Err:    err,
+			})
+			// Report only first error per flag.
+			break
+		}
+	})
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateRange returns validator which checks that numeric flag value is
+// within [min, max] range.
+func ValidateRange(min, max float64) func(flag.Value) error {
+	return func(v flag.Value) error {
+		x, err := number(v)
+		if err != nil {
+			return err
+		}
+		if x < min || x > max {
+			return fmt.Errorf(
+				"must be in range [%s, %s]",
+				formatFloat(min), formatFloat(max),
+			)
+		}
+		return nil
+	}
+}
+
+// ValidateRegexp returns validator which checks that flag value matches re.
+func ValidateRegexp(re *regexp.Regexp) func(flag.Value) error {
+	return func(v flag.Value) error {
+		if !re.MatchString(v.String()) {
+			return fmt.Errorf("must match %s", re)
+		}
+		return nil
+	}
+}
+
+// ValidateOneOf returns validator which checks that flag value is equal to
+// one of given options.
+func ValidateOneOf(options ...string) func(flag.Value) error {
+	return func(v flag.Value) error {
+		s := v.String()
+		for _, opt := range options {
+			if s == opt {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %q", options)
+	}
+}
+
+// ValidateNonEmpty returns validator which checks that flag value is not
+// empty. Values implementing flag.Getter which hold slices or maps are
+// checked to have at least one element.
+func ValidateNonEmpty() func(flag.Value) error {
+	return func(v flag.Value) error {
+		if g, ok := v.(flag.Getter); ok {
+			x := reflect.Indirect(reflect.ValueOf(g.Get()))
+			switch x.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				if x.Len() == 0 {
+					return fmt.Errorf("must not be empty")
+				}
+				return nil
+			}
+		}
+		if v.String() == "" {
+			return fmt.Errorf("must not be empty")
+		}
+		return nil
+	}
+}
+
+// ValidatePathExists returns validator which checks that flag value is a
+// path to existing file or directory.
+func ValidatePathExists() func(flag.Value) error {
+	return func(v flag.Value) error {
+		_, err := os.Stat(v.String())
+		if os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist")
+		}
+		return err
+	}
+}
+
+func number(v flag.Value) (float64, error) {
+	if g, ok := v.(flag.Getter); ok {
+		x := reflect.Indirect(reflect.ValueOf(g.Get()))
+		switch x.Kind() {
+		case
+			reflect.Int,
+			reflect.Int8,
+			reflect.Int16,
+			reflect.Int32,
+			reflect.Int64:
+			return float64(x.Int()), nil
+		case
+			reflect.Uint,
+			reflect.Uint8,
+			reflect.Uint16,
+			reflect.Uint32,
+			reflect.Uint64:
+			return float64(x.Uint()), nil
+		case
+			reflect.Float32,
+			reflect.Float64:
+			return x.Float(), nil
+		}
+	}
+	x, err := strconv.ParseFloat(v.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("must be a number")
+	}
+	return x, nil
+}
+
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
@@ -0,0 +1,147 @@
+package flagutil
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/gobwas/flagutil/parse"
+	"github.com/gobwas/flagutil/parse/file"
+)
+
+func TestValidators(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		validate func(flag.Value) error
+		value    flag.Value
+		err      bool
+	}{
+		{
+			name:     "range",
+			validate: ValidateRange(1, 10),
+			value:    intValue(5),
+		},
+		{
+			name:     "range out of bounds",
+			validate: ValidateRange(1, 10),
+			value:    intValue(11),
+			err:      true,
+		},
+		{
+			name:     "range not a number",
+			validate: ValidateRange(1, 10),
+			value:    stringFlag("", "foo", "").Value,
+			err:      true,
+		},
+		{
+			name:     "regexp",
+			validate: ValidateRegexp(regexp.MustCompile(`^[a-z]+$`)),
+			value:    stringFlag("", "foo", "").Value,
+		},
+		{
+			name:     "regexp mismatch",
+			validate: ValidateRegexp(regexp.MustCompile(`^[a-z]+$`)),
+			value:    stringFlag("", "foo42", "").Value,
+			err:      true,
+		},
+		{
+			name:     "one of",
+			validate: ValidateOneOf("debug", "info"),
+			value:    stringFlag("", "info", "").Value,
+		},
+		{
+			name:     "one of mismatch",
+			validate: ValidateOneOf("debug", "info"),
+			value:    stringFlag("", "error", "").Value,
+			err:      true,
+		},
+		{
+			name:     "non empty",
+			validate: ValidateNonEmpty(),
+			value:    stringFlag("", "", "").Value,
+			err:      true,
+		},
+		{
+			name:     "path exists",
+			validate: ValidatePathExists(),
+			value:    stringFlag("", os.TempDir(), "").Value,
+		},
+		{
+			name:     "path does not exist",
+			validate: ValidatePathExists(),
+			value:    stringFlag("", "/does/not/exist", "").Value,
+			err:      true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.validate(test.value)
+			if test.err && err == nil {
+				t.Fatalf("want error; got nothing")
+			}
+			if !test.err && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseValidation(t *testing.T) {
+	fs := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Int("port", 0, "")
+	fs.String("level", "info", "")
+	fs.String("name", "", "")
+
+	p := ParserFunc(func(_ context.Context, fs parse.FlagSet) error {
+		return fs.Set("port", "100000")
+	})
+	err := Parse(context.Background(), fs,
+		WithParser(p),
+		WithValidator("port", ValidateRange(1, 65535)),
+		WithValidator("level", ValidateOneOf("debug", "info")),
+		WithValidator("name", ValidateNonEmpty()),
+	)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want validation errors; got %v", err)
+	}
+	if n := len(errs); n != 2 {
+		t.Fatalf("unexpected number of errors: %d; want 2", n)
+	}
+	if act, exp := errs[0].Name, "name"; act != exp {
+		t.Errorf("unexpected flag name: %q; want %q", act, exp)
+	}
+	if errs[0].Source.Parser != nil {
+		t.Errorf("unexpected source of default value: %v", errs[0].Source)
+	}
+	if act, exp := errs[1].Name, "port"; act != exp {
+		t.Errorf("unexpected flag name: %q; want %q", act, exp)
+	}
+	if errs[1].Source.Parser == nil {
+		t.Errorf("want source of the value to be set")
+	}
+}
+
+func intValue(x int) flag.Value {
+	fs := flag.NewFlagSet("", flag.PanicOnError)
+	fs.Int("int", x, "")
+	return fs.Lookup("int").Value
+}
+
+func TestSourceString(t *testing.T) {
+	for _, test := range []struct {
+		src Source
+		exp string
+	}{
+		{Source{}, "<unknown>"},
+		{Source{Parser: new(file.Parser)}, "file"},
+		{Source{Parser: new(file.Parser), Location: "app.json"}, "file (app.json)"},
+		{Source{Parser: ParserFunc(nil)}, "flagutil.ParserFunc"},
+	} {
+		if act := test.src.String(); act != test.exp {
+			t.Errorf("unexpected string: %q; want %q", act, test.exp)
+		}
+	}
+}